@@ -0,0 +1,93 @@
+//go:build linux
+
+// Pscapture writes an archive of the processes in /proc that can later be
+// loaded with ps.LoadCapture.
+//
+// Usage:
+//
+//	pscapture [-z] [-noenv] [-redact NAME,...] [-root DIR] [FILE]
+//
+// The archive is written to FILE, or to standard output if FILE is not
+// specified or is "-".  The archive is compressed with gzip if -z is set or if
+// FILE ends in ".gz".  The values of the environment variables listed by
+// -redact are replaced in the archive.  A -redact of "*" redacts the values of
+// all environment variables.
+package main
+
+import (
+	"compress/gzip"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/pborman/ps"
+)
+
+func main() {
+	compress := flag.Bool("z", false, "compress the archive with gzip")
+	noEnv := flag.Bool("noenv", false, "do not capture process environments")
+	redact := flag.String("redact", "", "comma separated list of environment variables to redact (* for all)")
+	root := flag.String("root", "/proc", "procfs to capture")
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "usage: pscapture [-z] [-noenv] [-redact NAME,...] [-root DIR] [FILE]\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	var w io.Writer = os.Stdout
+	switch flag.NArg() {
+	case 0:
+	case 1:
+		name := flag.Arg(0)
+		if name == "-" {
+			break
+		}
+		f, err := os.Create(name)
+		if err != nil {
+			exit(err)
+		}
+		defer func() {
+			if err := f.Close(); err != nil {
+				exit(err)
+			}
+		}()
+		w = f
+		if strings.HasSuffix(name, ".gz") {
+			*compress = true
+		}
+	default:
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	opts := &ps.CaptureOptions{NoEnviron: *noEnv}
+	if *redact != "" {
+		names := map[string]bool{}
+		for _, name := range strings.Split(*redact, ",") {
+			names[name] = true
+		}
+		opts.Redact = func(name string) bool {
+			return names["*"] || names[name]
+		}
+	}
+
+	if *compress {
+		zw := gzip.NewWriter(w)
+		defer func() {
+			if err := zw.Close(); err != nil {
+				exit(err)
+			}
+		}()
+		w = zw
+	}
+	if err := ps.NewSource(*root).Capture(w, opts); err != nil {
+		exit(err)
+	}
+}
+
+func exit(err error) {
+	fmt.Fprintf(os.Stderr, "pscapture: %v\n", err)
+	os.Exit(1)
+}
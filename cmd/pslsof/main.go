@@ -0,0 +1,324 @@
+//go:build linux
+
+// Pslsof lists the files opened by processes, in the manner of lsof.
+//
+// Usage:
+//
+//	pslsof [-p PID,...] [-u USER,...] [-c NAME] [-i PORT] [-json] [-root DIR] [PATH ...]
+//
+// Every process that can be inspected is listed unless one or more filters
+// are given, in which case only the files matching all the filters are
+// listed.  -p selects processes by process ID and -u by user name or uid.  -c
+// selects processes by name, as matched by ps.ProcessByName.  -i selects
+// sockets with a local or remote port of PORT.  If any PATH is given then
+// only the uses of those files, or of the files beneath them if they are
+// directories, are listed.
+//
+// Each file is listed with the command, process ID and user of the process,
+// the use of the file (a file descriptor number followed by r, w or u for
+// read, write or read and write, or one of cwd, rtd, txt or mem for the
+// current directory, root directory, executable or a memory mapped file), its
+// type, its inode and its name.  With -json the files are written as a JSON
+// array instead.
+//
+// A static binary requires build tags.  Pslsof and the ps package use the
+// os/user and net packages, which are linked against the C library whenever
+// cgo is enabled, so a plain "go build" produces a dynamically linked binary.
+// To build pslsof as a static binary, such as for a minimal container image,
+// select their pure Go implementations:
+//
+//	go build -tags osusergo,netgo github.com/pborman/ps/cmd/pslsof
+//
+// or disable cgo altogether:
+//
+//	CGO_ENABLED=0 go build github.com/pborman/ps/cmd/pslsof
+//
+// When built this way, user names are only looked up in /etc/passwd.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"os/user"
+	"strconv"
+	"strings"
+	"syscall"
+	"text/tabwriter"
+
+	"github.com/pborman/ps"
+)
+
+// A file is a single file used by a process.
+type file struct {
+	Command string `json:"command"`
+	PID     int    `json:"pid"`
+	User    string `json:"user"`
+	FD      string `json:"fd"`
+	Type    string `json:"type"`
+	Inode   uint64 `json:"inode,omitempty"`
+	Name    string `json:"name"`
+
+	use ps.Use // the use of the file, for matching against paths
+}
+
+func main() {
+	pids := flag.String("p", "", "comma separated list of process IDs to list")
+	users := flag.String("u", "", "comma separated list of users (names or uids) to list")
+	name := flag.String("c", "", "list processes with this name")
+	port := flag.Int("i", 0, "list sockets using this port")
+	asJSON := flag.Bool("json", false, "write the files as JSON")
+	root := flag.String("root", "/proc", "procfs to read")
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "usage: pslsof [-p PID,...] [-u USER,...] [-c NAME] [-i PORT] [-json] [-root DIR] [PATH ...]\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	src := ps.NewSource(*root)
+	var procs []*ps.Process
+	var err error
+	if *name != "" {
+		procs, err = src.ProcessByName(*name)
+	} else {
+		procs, err = src.Processes(false)
+	}
+	if err != nil {
+		exit(err)
+	}
+	if *pids != "" {
+		want := map[int]bool{}
+		for _, s := range strings.Split(*pids, ",") {
+			pid, err := strconv.Atoi(s)
+			if err != nil {
+				exit(fmt.Errorf("invalid process ID %q", s))
+			}
+			want[pid] = true
+		}
+		procs = filter(procs, func(p *ps.Process) bool { return want[p.ID] })
+	}
+	if *users != "" {
+		want := map[int]bool{}
+		for _, s := range strings.Split(*users, ",") {
+			uid, err := lookupUid(s)
+			if err != nil {
+				exit(err)
+			}
+			want[uid] = true
+		}
+		procs = filter(procs, func(p *ps.Process) bool {
+			uid, err := p.Uid()
+			return err == nil && want[uid]
+		})
+	}
+
+	// The uses of the requested paths, indexed by process ID.
+	var uses map[int][]ps.Use
+	if flag.NArg() > 0 {
+		uses = map[int][]ps.Use{}
+		for _, path := range flag.Args() {
+			u, err := src.ProcessesUsing(path)
+			if err != nil {
+				exit(err)
+			}
+			for _, use := range u {
+				uses[use.Process.ID] = append(uses[use.Process.ID], use)
+			}
+		}
+	}
+
+	// Processes in the same network namespace share its network tables.
+	cache := &ps.ConnectionCache{}
+	var files []file
+	for _, p := range procs {
+		if uses != nil && uses[p.ID] == nil {
+			continue
+		}
+		for _, f := range listFiles(p, *port, cache) {
+			if uses == nil || matches(f.use, uses[p.ID]) {
+				files = append(files, f)
+			}
+		}
+	}
+
+	if *asJSON {
+		if files == nil {
+			files = []file{}
+		}
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(files); err != nil {
+			exit(err)
+		}
+		return
+	}
+	tw := tabwriter.NewWriter(os.Stdout, 0, 8, 1, ' ', 0)
+	fmt.Fprintf(tw, "COMMAND\tPID\tUSER\tFD\tTYPE\tNODE\tNAME\n")
+	for _, f := range files {
+		node := ""
+		if f.Inode != 0 {
+			node = strconv.FormatUint(f.Inode, 10)
+		}
+		fmt.Fprintf(tw, "%s\t%d\t%s\t%s\t%s\t%s\t%s\n", f.Command, f.PID, f.User, f.FD, f.Type, node, f.Name)
+	}
+	if err := tw.Flush(); err != nil {
+		exit(err)
+	}
+}
+
+// listFiles returns the files used by p.  The connections of p are found
+// through cache.  If port is not 0 then only the sockets using port are
+// returned.  Files that cannot be read, normally because p belongs to a
+// different user, are not returned.
+func listFiles(p *ps.Process, port int, cache *ps.ConnectionCache) []file {
+	command, err := p.Command()
+	if err != nil {
+		return nil // p has exited
+	}
+	username := ""
+	if uid, err := p.Uid(); err == nil {
+		username = userName(uid)
+	}
+	var files []file
+	add := func(fd, typ string, inode uint64, name string, use ps.Use) {
+		files = append(files, file{
+			Command: command,
+			PID:     p.ID,
+			User:    username,
+			FD:      fd,
+			Type:    typ,
+			Inode:   inode,
+			Name:    name,
+			use:     use,
+		})
+	}
+
+	fds, fdErr := p.FDs()
+	conns := map[int]ps.Connection{}
+	if fdErr == nil {
+		if cs, err := cache.Connections(p, fds); err == nil {
+			for _, c := range cs {
+				conns[c.FD] = c
+			}
+		}
+	}
+
+	if port == 0 {
+		for _, link := range []struct {
+			fd     string
+			kind   ps.UseKind
+			target func() (string, error)
+		}{
+			{"cwd", ps.UseCwd, p.Cwd},
+			{"rtd", ps.UseRoot, p.Root},
+			{"txt", ps.UseExe, p.Path},
+		} {
+			target, err := link.target()
+			if err != nil {
+				continue
+			}
+			add(link.fd, "file", 0, target, ps.Use{Kind: link.kind, Path: target})
+		}
+		if maps, err := p.Maps(); err == nil {
+			seen := map[string]bool{}
+			for _, m := range maps {
+				if !m.IsFileBacked() || seen[m.Path] {
+					continue
+				}
+				seen[m.Path] = true
+				add("mem", "file", m.Inode, m.Path, ps.Use{Kind: ps.UseMapped, Path: m.Path})
+			}
+		}
+	}
+
+	if fdErr != nil {
+		return files
+	}
+	for _, fd := range fds {
+		name := fd.Target
+		var conn *ps.Connection
+		if c, ok := conns[fd.FD]; ok {
+			conn = &c
+			name = c.String()
+		}
+		if port != 0 && !usesPort(conn, port) {
+			continue
+		}
+		add(fdName(fd), fd.Kind.String(), fd.Inode, name, ps.Use{Kind: ps.UseFD, FD: fd.FD, Path: fd.Path()})
+	}
+	return files
+}
+
+// usesPort returns true if c, the connection of a file, is an internet socket
+// with a local or remote port of port.  c is nil if the file is not a socket.
+func usesPort(c *ps.Connection, port int) bool {
+	return c != nil && c.Proto != "unix" && (c.LocalPort == port || c.RemotePort == port)
+}
+
+// fdName returns the name of fd as shown in the FD column, such as "3u".
+func fdName(fd ps.FD) string {
+	mode := ""
+	switch fd.Flags & syscall.O_ACCMODE {
+	case syscall.O_RDONLY:
+		mode = "r"
+	case syscall.O_WRONLY:
+		mode = "w"
+	case syscall.O_RDWR:
+		mode = "u"
+	}
+	return strconv.Itoa(fd.FD) + mode
+}
+
+// matches returns true if use is one of uses.
+func matches(use ps.Use, uses []ps.Use) bool {
+	for _, u := range uses {
+		if u.Kind == use.Kind && u.FD == use.FD && u.Path == strings.TrimSuffix(use.Path, " (deleted)") {
+			return true
+		}
+	}
+	return false
+}
+
+func filter(procs []*ps.Process, keep func(*ps.Process) bool) []*ps.Process {
+	var kept []*ps.Process
+	for _, p := range procs {
+		if keep(p) {
+			kept = append(kept, p)
+		}
+	}
+	return kept
+}
+
+// lookupUid returns the uid of the user named s, or s itself if s is numeric.
+func lookupUid(s string) (int, error) {
+	if uid, err := strconv.Atoi(s); err == nil {
+		return uid, nil
+	}
+	u, err := user.Lookup(s)
+	if err != nil {
+		return 0, err
+	}
+	return strconv.Atoi(u.Uid)
+}
+
+var userNames = map[int]string{}
+
+// userName returns the name of the user with the provided uid, or the uid
+// itself if the user is not known.
+func userName(uid int) string {
+	if name, ok := userNames[uid]; ok {
+		return name
+	}
+	name := strconv.Itoa(uid)
+	if u, err := user.LookupId(name); err == nil {
+		name = u.Username
+	}
+	userNames[uid] = name
+	return name
+}
+
+func exit(err error) {
+	fmt.Fprintf(os.Stderr, "pslsof: %v\n", err)
+	os.Exit(1)
+}
@@ -0,0 +1,127 @@
+//go:build linux
+
+package main
+
+import (
+	"io/fs"
+	"syscall"
+	"testing"
+	"testing/fstest"
+
+	"github.com/pborman/ps"
+)
+
+func link(target string) *fstest.MapFile {
+	return &fstest.MapFile{Data: []byte(target), Mode: fs.ModeSymlink | 0777}
+}
+
+func TestListFiles(t *testing.T) {
+	fsys := fstest.MapFS{
+		"1/stat":     &fstest.MapFile{Data: []byte("1 (sshd) S 0 1 1 0 -1 4194560 0 0 0 0 0 0 0 0 20 0 1 0 10 0 0 18446744073709551615 0 0 0 0 0 0 0 0 0 0 0 0 17 0 0 0 0 0 0 0 0 0 0 0 0 0 0\n")},
+		"1/exe":      link("/usr/sbin/sshd"),
+		"1/cwd":      link("/"),
+		"1/root":     link("/"),
+		"1/ns/net":   link("net:[4026531840]"),
+		"1/fd/0":     link("/dev/null"),
+		"1/fd/3":     link("socket:[12345]"),
+		"1/fd/4":     link("socket:[12346]"),
+		"1/fdinfo/0": &fstest.MapFile{Data: []byte("pos:\t0\nflags:\t0100002\n")},
+		"1/fdinfo/3": &fstest.MapFile{Data: []byte("pos:\t0\nflags:\t02000002\n")},
+		"1/fdinfo/4": &fstest.MapFile{Data: []byte("pos:\t0\nflags:\t02000002\n")},
+		"1/net/tcp": &fstest.MapFile{Data: []byte(`  sl  local_address rem_address   st tx_queue rx_queue tr tm->when retrnsmt   uid  timeout inode
+   0: 00000000:0016 00000000:0000 0A 00000000:00000000 00:00000000 00000000     0        0 12345 1 0000000000000000 100 0 0 10 0
+   1: 0100007F:1F90 0100007F:A2C4 01 00000000:00000000 00:00000000 00000000     0        0 12346 1 0000000000000000 20 4 30 10 -1
+`)},
+	}
+	p, err := ps.NewSourceFS(fsys).ProcessByPid(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tt := range []struct {
+		port int
+		want []string
+	}{
+		{0, []string{
+			"cwd /",
+			"rtd /",
+			"txt /usr/sbin/sshd",
+			"0u /dev/null",
+			"3u tcp 0.0.0.0:22->0.0.0.0:0 LISTEN",
+			"4u tcp 127.0.0.1:8080->127.0.0.1:41668 ESTABLISHED",
+		}},
+		{22, []string{"3u tcp 0.0.0.0:22->0.0.0.0:0 LISTEN"}},
+		{41668, []string{"4u tcp 127.0.0.1:8080->127.0.0.1:41668 ESTABLISHED"}},
+		{80, nil},
+	} {
+		var got []string
+		for _, f := range listFiles(p, tt.port, &ps.ConnectionCache{}) {
+			got = append(got, f.FD+" "+f.Name)
+		}
+		if len(got) != len(tt.want) {
+			t.Errorf("port %d: got %q, want %q", tt.port, got, tt.want)
+			continue
+		}
+		for i := range got {
+			if got[i] != tt.want[i] {
+				t.Errorf("port %d: got %q, want %q", tt.port, got, tt.want)
+				break
+			}
+		}
+	}
+}
+
+func TestUsesPort(t *testing.T) {
+	for _, tt := range []struct {
+		c    *ps.Connection
+		port int
+		want bool
+	}{
+		{nil, 22, false},
+		{&ps.Connection{Proto: "tcp", LocalPort: 22}, 22, true},
+		{&ps.Connection{Proto: "tcp6", LocalPort: 41668, RemotePort: 22}, 22, true},
+		{&ps.Connection{Proto: "udp", LocalPort: 53}, 22, false},
+		{&ps.Connection{Proto: "unix"}, 0, false},
+	} {
+		if got := usesPort(tt.c, tt.port); got != tt.want {
+			t.Errorf("usesPort(%+v, %d) got %v, want %v", tt.c, tt.port, got, tt.want)
+		}
+	}
+}
+
+func TestFDName(t *testing.T) {
+	for _, tt := range []struct {
+		fd   ps.FD
+		want string
+	}{
+		{ps.FD{FD: 0, Flags: syscall.O_RDONLY}, "0r"},
+		{ps.FD{FD: 1, Flags: syscall.O_WRONLY | syscall.O_APPEND}, "1w"},
+		{ps.FD{FD: 3, Flags: syscall.O_RDWR | syscall.O_CLOEXEC}, "3u"},
+		{ps.FD{FD: 4, Flags: syscall.O_ACCMODE}, "4"},
+	} {
+		if got := fdName(tt.fd); got != tt.want {
+			t.Errorf("fdName(%+v) got %q, want %q", tt.fd, got, tt.want)
+		}
+	}
+}
+
+func TestMatches(t *testing.T) {
+	uses := []ps.Use{
+		{Kind: ps.UseFD, FD: 3, Path: "/var/log/syslog"},
+		{Kind: ps.UseCwd, Path: "/var/log"},
+	}
+	for _, tt := range []struct {
+		use  ps.Use
+		want bool
+	}{
+		{ps.Use{Kind: ps.UseFD, FD: 3, Path: "/var/log/syslog"}, true},
+		{ps.Use{Kind: ps.UseFD, FD: 3, Path: "/var/log/syslog (deleted)"}, true},
+		{ps.Use{Kind: ps.UseFD, FD: 4, Path: "/var/log/syslog"}, false},
+		{ps.Use{Kind: ps.UseCwd, Path: "/var/log"}, true},
+		{ps.Use{Kind: ps.UseRoot, Path: "/var/log"}, false},
+		{ps.Use{Kind: ps.UseMapped, Path: "/usr/lib/libc.so.6"}, false},
+	} {
+		if got := matches(tt.use, uses); got != tt.want {
+			t.Errorf("matches(%+v) got %v, want %v", tt.use, got, tt.want)
+		}
+	}
+}
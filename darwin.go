@@ -2,7 +2,12 @@
 
 package ps
 
-import "strings"
+import (
+	"errors"
+	"strings"
+	"syscall"
+	"time"
+)
 
 // commLen is the maximum length of name that Command() will return.
 // zero means no limit.
@@ -21,6 +26,8 @@ type Process struct {
 	rusage   *RUsage
 	cpath    string
 	argenv   *argenv
+	start    syscall.Timeval // Starttime when kinfo was first read, if hasStart
+	hasStart bool
 }
 
 func (p *Process) clean() {
@@ -55,6 +62,35 @@ func (p *Process) gid() (int, error) {
 	return int(p.kinfo.Gid), nil
 }
 
+func (p *Process) uidCreds() (Creds, error) {
+	if err := p.fillKinfo(); err != nil {
+		return Creds{}, err
+	}
+	return Creds{
+		Real:      int(p.kinfo.Uid),
+		Effective: int(p.kinfo.Euid),
+		Saved:     int(p.kinfo.Svuid),
+		FS:        int(p.kinfo.Euid),
+	}, nil
+}
+
+func (p *Process) gidCreds() (Creds, error) {
+	if err := p.fillKinfo(); err != nil {
+		return Creds{}, err
+	}
+	// The effective group id is the first entry of cr_groups.
+	egid := p.kinfo.Gid
+	if p.kinfo.Ngroups > 0 {
+		egid = p.kinfo.Groups[0]
+	}
+	return Creds{
+		Real:      int(p.kinfo.Gid),
+		Effective: int(egid),
+		Saved:     int(p.kinfo.Svgid),
+		FS:        int(egid),
+	}, nil
+}
+
 func (p *Process) groups() ([]int, error) {
 	if err := p.fillKinfo(); err != nil {
 		return nil, err
@@ -66,6 +102,38 @@ func (p *Process) groups() ([]int, error) {
 	return groups, nil
 }
 
+func (p *Process) startTime() (time.Time, error) {
+	if err := p.fillKinfo(); err != nil {
+		return time.Time{}, err
+	}
+	tv := p.kinfo.Starttime
+	return time.Unix(int64(tv.Sec), int64(tv.Usec)*1000), nil
+}
+
+func (p *Process) cpuTime() (time.Duration, error) {
+	if err := p.fillRUsage(); err != nil {
+		return 0, err
+	}
+	return machToDuration(p.rusage.UserTime + p.rusage.SystemTime), nil
+}
+
+func (p *Process) state() (State, error) {
+	if err := p.fillKinfo(); err != nil {
+		return StateUnknown, err
+	}
+	switch p.kinfo.Stat {
+	case SRUN:
+		return StateRunning, nil
+	case SSLEEP:
+		return StateSleeping, nil
+	case SSTOP:
+		return StateStopped, nil
+	case SZOMB:
+		return StateZombie, nil
+	}
+	return StateUnknown, nil
+}
+
 func (p *Process) tty() (string, error) {
 	if err := p.fillKinfo(); err != nil {
 		return "", err
@@ -155,10 +223,42 @@ func (p *Process) fillKinfo() error {
 		return nil
 	}
 	var err error
-	p.kinfo, err = getKInfoPid(p.ID)
+	if p.kinfo, err = getKInfoPid(p.ID); err == nil && !p.hasStart {
+		p.start, p.hasStart = p.kinfo.Starttime, true
+	}
 	return err
 }
 
+// current returns a new Process for the ID of p after checking that it is
+// still the process p refers to.  ErrReused is returned if the ID of p now
+// belongs to a process with a different start time.
+func (p *Process) current() (*Process, error) {
+	if !p.hasStart {
+		return nil, ErrNoStartTime
+	}
+	q, err := processByPid(p.ID)
+	if err != nil {
+		return nil, err
+	}
+	if q.kinfo.Starttime != p.start {
+		return nil, ErrReused
+	}
+	return q, nil
+}
+
+// signal sends sig to p if p is still the process it refers to.
+func (p *Process) signal(sig syscall.Signal) error {
+	if _, err := p.current(); err != nil {
+		return err
+	}
+	return syscall.Kill(p.ID, sig)
+}
+
+// freezeCgroup returns an error as there are no cgroups on darwin.
+func freezeCgroup(pid int) (func() error, error) {
+	return nil, errors.New("cgroups are only available on linux")
+}
+
 func (p *Process) fillRUsage() error {
 	if p.rusage != nil {
 		return nil
@@ -187,8 +287,10 @@ func processByPid(pid int) (*Process, error) {
 		return nil, err
 	}
 	return &Process{
-		ID:    pid,
-		kinfo: ki,
+		ID:       pid,
+		kinfo:    ki,
+		start:    ki.Starttime,
+		hasStart: true,
 	}, nil
 }
 
@@ -221,8 +323,10 @@ func fullProcesses() ([]*Process, error) {
 	p := make([]*Process, len(procs))
 	for i, ki := range procs {
 		p[i] = &Process{
-			ID:    int(ki.Pid),
-			kinfo: ki,
+			ID:       int(ki.Pid),
+			kinfo:    ki,
+			start:    ki.Starttime,
+			hasStart: true,
 		}
 	}
 	return p, nil
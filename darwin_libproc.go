@@ -5,9 +5,12 @@ package ps
 //#include <sys/types.h>
 //#include <sys/resource.h>
 //#include <libproc.h>
+//#include <mach/mach_time.h>
 import "C"
 
 import (
+	"sync"
+	"time"
 	"unsafe"
 )
 
@@ -82,6 +85,24 @@ func pidrusage(pid int) (*RUsage, error) {
 	return &ri, err
 }
 
+var (
+	timebaseOnce sync.Once
+	timebase     C.mach_timebase_info_data_t
+)
+
+// machToDuration converts t, in mach absolute time units as used by the times
+// in RUsage, to a time.Duration.
+func machToDuration(t uint64) time.Duration {
+	timebaseOnce.Do(func() {
+		if C.mach_timebase_info(&timebase) != 0 || timebase.denom == 0 {
+			timebase.numer = 1
+			timebase.denom = 1
+		}
+	})
+	numer, denom := uint64(timebase.numer), uint64(timebase.denom)
+	return time.Duration(t/denom*numer + t%denom*numer/denom)
+}
+
 func listallpids() ([]int32, error) {
 	maxproc, err := maxProc()
 	if err != nil {
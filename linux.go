@@ -6,12 +6,12 @@ import (
 	"bytes"
 	"errors"
 	"fmt"
-	"io/ioutil"
 	"os"
 	"strconv"
 	"strings"
 	"sync"
 	"syscall"
+	"time"
 )
 
 // A Process represents a process.  A Process will cache any information
@@ -23,13 +23,17 @@ import (
 type Process struct {
 	ID       int        // The process ID
 	Children []*Process // Only filled in by GetProcessMap
+	src      *Source
 	dir      string
+	tgid     int
 	cpath    string
 	stat     *Stat
 	sysstat  *syscall.Stat_t
 	comm     string
 	cgroups  []int
 	status   map[string]StatusValue
+	start    uint64 // Starttime when the stat was first read, if hasStart
+	hasStart bool
 }
 
 type StatusValue string
@@ -112,7 +116,7 @@ func (p *Process) Stat(refresh ...bool) (*Stat, error) {
 	if p.stat != nil {
 		return p.stat, nil
 	}
-	data, err := ioutil.ReadFile(p.dirname() + "/stat")
+	data, err := p.source().readFile(p.dirname() + "/stat")
 	if err != nil {
 		err = fixError(err)
 		return nil, err
@@ -239,22 +243,18 @@ func (p *Process) Stat(refresh ...bool) (*Stat, error) {
 	s.ExitCode = getint()
 	if rerr == nil {
 		p.stat = &s
+		if !p.hasStart {
+			p.start, p.hasStart = s.Starttime, true
+		}
 	}
 	return p.stat, rerr
 }
 
-func (p *Process) footprint(refresh ...bool) (int, error) {
-	if _, err := p.Stat(refresh...); err != nil {
-		return 0, err
-	}
-	return int(p.stat.Vsize), nil
-}
-
 func (p *Process) groups() ([]int, error) {
 	if p.cgroups != nil {
 		return p.cgroups, nil
 	}
-	data, err := ioutil.ReadFile(p.dirname() + "/status")
+	data, err := p.source().readFile(p.dirname() + "/status")
 	if err != nil {
 		err = fixError(err)
 		return nil, err
@@ -289,7 +289,7 @@ const (
 	stHex
 	stSize
 	stByte
-	stID // real, effective, saved, filesystem (unused)
+	stID // real, effective, saved, filesystem
 	stArray
 	stRange
 	stHexList
@@ -305,14 +305,14 @@ var statusTypes = map[string]int{
 	"Pid":                        stDecimal,
 	"PPid":                       stDecimal,
 	"TracerPid":                  stDecimal,
-	"Uid":                        stArray,
-	"Gid":                        stArray,
+	"Uid":                        stID,
+	"Gid":                        stID,
 	"FDSize":                     stDecimal,
 	"Groups":                     stArray,
-	"NStgid":                     stDecimal,
-	"NSpid":                      stDecimal,
-	"NSpgid":                     stDecimal,
-	"NSsid":                      stDecimal,
+	"NStgid":                     stArray,
+	"NSpid":                      stArray,
+	"NSpgid":                     stArray,
+	"NSsid":                      stArray,
 	"VmPeak":                     stSize,
 	"VmSize":                     stSize,
 	"VmLck":                      stSize,
@@ -345,10 +345,10 @@ var statusTypes = map[string]int{
 	"NoNewPrivs":                 stDecimal,
 	"Seccomp":                    stDecimal,
 	"Speculation_Store_Bypass":   stString,
-	"Cpus_allowed":               stHex,
+	"Cpus_allowed":               stHexList,
 	"Cpus_allowed_list":          stRange,
 	"Mems_allowed":               stHexList,
-	"Mems_allowed_list":          stDecimal,
+	"Mems_allowed_list":          stRange,
 	"voluntary_ctxt_switches":    stDecimal,
 	"nonvoluntary_ctxt_switches": stDecimal,
 }
@@ -359,7 +359,7 @@ func (p *Process) StatusMap(refresh ...bool) (map[string]StatusValue, error) {
 	if p.status != nil && (len(refresh) == 0 || !refresh[0]) {
 		return p.status, nil
 	}
-	data, err := ioutil.ReadFile(p.dirname() + "/status")
+	data, err := p.source().readFile(p.dirname() + "/status")
 	if err != nil {
 		err = fixError(err)
 		p.status = nil
@@ -381,7 +381,7 @@ func (p *Process) StatusMap(refresh ...bool) (map[string]StatusValue, error) {
 // StatusMap if multiple values are needed.
 // StatusValue is only available on linux.
 func (p *Process) StatusValue(name string) (StatusValue, error) {
-	data, err := ioutil.ReadFile(p.dirname() + "/status")
+	data, err := p.source().readFile(p.dirname() + "/status")
 	if err != nil {
 		err = fixError(err)
 		return "", err
@@ -416,7 +416,13 @@ func (s StatusValue) AsHex() (int64, error) {
 	return strconv.ParseInt(string(s), 16, 64)
 }
 
-// AsOctal returns the numeric value of s assuming it is an array of decimal.
+// AsUnsignedHex returns the numeric value of s assuming it is hexadecimal.
+// Unlike AsHex, it can decode masks with the high bit set, such as CapBnd.
+func (s StatusValue) AsUnsignedHex() (uint64, error) {
+	return strconv.ParseUint(string(s), 16, 64)
+}
+
+// AsArray returns the numeric values of s assuming it is an array of decimal.
 func (s StatusValue) AsArray() ([]int64, error) {
 	a := strings.Fields(string(s))
 	vs := make([]int64, len(a))
@@ -430,13 +436,76 @@ func (s StatusValue) AsArray() ([]int64, error) {
 	return vs, nil
 }
 
-// Creds contains the credentials for a process.
-// Creds is only available on linux.
-type Creds struct {
-	Real      int
-	Effective int
-	Saved     int
-	FS        int
+// AsRange returns the numbers in s assuming it is a list of ranges, such as
+// "0-3,8,10-11" (Cpus_allowed_list).
+func (s StatusValue) AsRange() ([]int, error) {
+	if s == "" {
+		return nil, nil
+	}
+	var vs []int
+	for _, r := range strings.Split(string(s), ",") {
+		lo, hi := r, r
+		if x := strings.IndexByte(r, '-'); x >= 0 {
+			lo, hi = r[:x], r[x+1:]
+		}
+		start, err := strconv.Atoi(lo)
+		if err != nil {
+			return nil, err
+		}
+		end, err := strconv.Atoi(hi)
+		if err != nil {
+			return nil, err
+		}
+		if end < start {
+			return nil, fmt.Errorf("invalid range %q", r)
+		}
+		for i := start; i <= end; i++ {
+			vs = append(vs, i)
+		}
+	}
+	return vs, nil
+}
+
+// AsHexList returns the numbers of the bits set in s assuming it is a mask of
+// comma separated 32 bit hexadecimal words, most significant word first, such
+// as "00000000,00000001" (Mems_allowed).
+func (s StatusValue) AsHexList() ([]int, error) {
+	if s == "" {
+		return nil, nil
+	}
+	words := strings.Split(string(s), ",")
+	var vs []int
+	for i := len(words) - 1; i >= 0; i-- {
+		w, err := strconv.ParseUint(words[i], 16, 32)
+		if err != nil {
+			return nil, err
+		}
+		base := 32 * (len(words) - 1 - i)
+		for bit := 0; w != 0; bit, w = bit+1, w>>1 {
+			if w&1 != 0 {
+				vs = append(vs, base+bit)
+			}
+		}
+	}
+	return vs, nil
+}
+
+// AsSlash returns the two numbers in s assuming it is of the form "N/M", such
+// as "0/63704" (SigQ).
+func (s StatusValue) AsSlash() (int64, int64, error) {
+	x := strings.IndexByte(string(s), '/')
+	if x < 0 {
+		return 0, 0, errors.New("missing /")
+	}
+	n, err := strconv.ParseInt(string(s[:x]), 10, 64)
+	if err != nil {
+		return 0, 0, err
+	}
+	m, err := strconv.ParseInt(string(s[x+1:]), 10, 64)
+	if err != nil {
+		return 0, 0, err
+	}
+	return n, m, nil
 }
 
 // AsCreds returns s interpreted as a Creds structure.
@@ -483,53 +552,60 @@ func (s StatusValue) AsSize(value string) (int64, error) {
 }
 
 func processByPid(pid int) (*Process, error) {
-	p := &Process{
-		ID: pid,
-	}
-	if err := p.getStat(); err != nil {
-		return nil, err
-	}
-	return p, nil
+	return defaultSource.ProcessByPid(pid)
 }
 
 func (p *Process) getStat() error {
 	if p.sysstat != nil {
 		return nil
 	}
-	var stat syscall.Stat_t
-	if err := syscall.Stat(p.dirname(), &stat); err != nil {
+	stat, err := p.source().stat(p.dirname())
+	if err != nil {
 		return err
 	}
-	p.sysstat = &stat
+	p.sysstat = stat
 	return nil
 }
 
+// current returns a new Process for the ID of p after checking that it is
+// still the process p refers to.  ErrReused is returned if the ID of p now
+// belongs to a process with a different start time.
+func (p *Process) current() (*Process, error) {
+	if p.source().fsys != nil {
+		return nil, ErrNotLive
+	}
+	if !p.hasStart {
+		return nil, ErrNoStartTime
+	}
+	q := &Process{ID: p.ID, src: p.src, dir: p.dir}
+	cur, err := q.Stat()
+	if err != nil {
+		return nil, err
+	}
+	if cur.Starttime != p.start {
+		return nil, ErrReused
+	}
+	return q, nil
+}
+
+// source returns the Source p was read from.  A Process created directly by
+// the caller uses the default source.
+func (p *Process) source() *Source {
+	if p.src == nil {
+		return defaultSource
+	}
+	return p.src
+}
+
 func (p *Process) dirname() string {
 	if p.dir == "" {
-		p.dir = "/proc/" + strconv.Itoa(p.ID)
+		p.dir = p.source().dirname(p.ID)
 	}
 	return p.dir
 }
 
 func processes(filled bool) ([]*Process, error) {
-	pids, err := listallpids()
-	if err != nil {
-		return nil, err
-	}
-	p := make([]*Process, 0, len(pids))
-	for _, pid := range pids {
-		pr := &Process{
-			ID:  pid,
-			dir: "/proc/" + strconv.Itoa(pid),
-		}
-		if filled {
-			if err := pr.getStat(); err != nil {
-				continue
-			}
-		}
-		p = append(p, pr)
-	}
-	return p, nil
+	return defaultSource.Processes(filled)
 }
 
 func (p *Process) pid() int {
@@ -543,6 +619,36 @@ func (p *Process) ppid() (int, error) {
 	return p.stat.Ppid, nil
 }
 
+func (p *Process) cpuTime() (time.Duration, error) {
+	s, err := p.Stat()
+	if err != nil {
+		return 0, err
+	}
+	return s.UserTime() + s.SystemTime(), nil
+}
+
+func (p *Process) state() (State, error) {
+	s, err := p.Stat()
+	if err != nil {
+		return StateUnknown, err
+	}
+	switch s.State {
+	case 'R':
+		return StateRunning, nil
+	case 'S':
+		return StateSleeping, nil
+	case 'D':
+		return StateDiskWait, nil
+	case 'T', 't':
+		return StateStopped, nil
+	case 'Z':
+		return StateZombie, nil
+	case 'I':
+		return StateIdle, nil
+	}
+	return StateUnknown, nil
+}
+
 func (p *Process) uid() (int, error) {
 	if err := p.getStat(); err != nil {
 		return 0, err
@@ -557,15 +663,49 @@ func (p *Process) gid() (int, error) {
 	return int(p.sysstat.Gid), nil
 }
 
+func (p *Process) uidCreds() (Creds, error) {
+	v, err := p.StatusValue("Uid")
+	if err != nil {
+		return Creds{}, err
+	}
+	return v.AsCreds()
+}
+
+func (p *Process) gidCreds() (Creds, error) {
+	v, err := p.StatusValue("Gid")
+	if err != nil {
+		return Creds{}, err
+	}
+	return v.AsCreds()
+}
+
 func (p *Process) path() (string, error) {
 	var err error
 	if p.cpath == "" {
-		p.cpath, err = os.Readlink(p.dirname() + "/exe")
+		p.cpath, err = p.source().readlink(p.dirname() + "/exe")
 		err = fixError(err)
 	}
 	return p.cpath, err
 }
 
+// Cwd returns the current working directory of p.  Non-root users will
+// receive an error when requesting information about a process with a
+// different UID.
+// Cwd is only available on linux.
+func (p *Process) Cwd() (string, error) {
+	dir, err := p.source().readlink(p.dirname() + "/cwd")
+	return dir, fixError(err)
+}
+
+// Root returns the root directory of p, normally "/".  Non-root users will
+// receive an error when requesting information about a process with a
+// different UID.
+// Root is only available on linux.
+func (p *Process) Root() (string, error) {
+	dir, err := p.source().readlink(p.dirname() + "/root")
+	return dir, fixError(err)
+}
+
 func (p *Process) command() (string, error) {
 	if _, err := p.Path(); err != nil {
 		s, err := p.Stat()
@@ -579,7 +719,7 @@ func (p *Process) command() (string, error) {
 
 func (p *Process) getStrings(name string) ([]string, error) {
 	s, err := p.stringFile(name)
-	if err != nil {
+	if err != nil || s == "" {
 		return nil, err
 	}
 	if s[len(s)-1] == 0 {
@@ -623,30 +763,11 @@ func (p *Process) value(name string) (string, error) {
 }
 
 func (p *Process) stringFile(name string) (string, error) {
-	data, err := ioutil.ReadFile(p.dirname() + name)
+	data, err := p.source().readFile(p.dirname() + name)
 	err = fixError(err)
 	return string(data), err
 }
 
-func listallpids() ([]int, error) {
-	f, err := os.Open("/proc")
-	if err != nil {
-		return nil, err
-	}
-	names, err := f.Readdirnames(-1)
-	f.Close()
-	if err != nil {
-		return nil, err
-	}
-	var pids []int
-	for _, name := range names {
-		if pid, err := strconv.Atoi(name); err == nil {
-			pids = append(pids, pid)
-		}
-	}
-	return pids, nil
-}
-
 func (p *Process) tty() (string, error) {
 	if _, err := p.Stat(); err != nil {
 		return "", err
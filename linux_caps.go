@@ -0,0 +1,222 @@
+//go:build linux
+
+package ps
+
+import (
+	"fmt"
+	"math/bits"
+	"strings"
+)
+
+// A Capability is a Linux capability, as described in capabilities(7).
+// Capability is only available on linux.
+type Capability int
+
+const (
+	CAP_CHOWN              = Capability(0)
+	CAP_DAC_OVERRIDE       = Capability(1)
+	CAP_DAC_READ_SEARCH    = Capability(2)
+	CAP_FOWNER             = Capability(3)
+	CAP_FSETID             = Capability(4)
+	CAP_KILL               = Capability(5)
+	CAP_SETGID             = Capability(6)
+	CAP_SETUID             = Capability(7)
+	CAP_SETPCAP            = Capability(8)
+	CAP_LINUX_IMMUTABLE    = Capability(9)
+	CAP_NET_BIND_SERVICE   = Capability(10)
+	CAP_NET_BROADCAST      = Capability(11)
+	CAP_NET_ADMIN          = Capability(12)
+	CAP_NET_RAW            = Capability(13)
+	CAP_IPC_LOCK           = Capability(14)
+	CAP_IPC_OWNER          = Capability(15)
+	CAP_SYS_MODULE         = Capability(16)
+	CAP_SYS_RAWIO          = Capability(17)
+	CAP_SYS_CHROOT         = Capability(18)
+	CAP_SYS_PTRACE         = Capability(19)
+	CAP_SYS_PACCT          = Capability(20)
+	CAP_SYS_ADMIN          = Capability(21)
+	CAP_SYS_BOOT           = Capability(22)
+	CAP_SYS_NICE           = Capability(23)
+	CAP_SYS_RESOURCE       = Capability(24)
+	CAP_SYS_TIME           = Capability(25)
+	CAP_SYS_TTY_CONFIG     = Capability(26)
+	CAP_MKNOD              = Capability(27)
+	CAP_LEASE              = Capability(28)
+	CAP_AUDIT_WRITE        = Capability(29)
+	CAP_AUDIT_CONTROL      = Capability(30)
+	CAP_SETFCAP            = Capability(31)
+	CAP_MAC_OVERRIDE       = Capability(32)
+	CAP_MAC_ADMIN          = Capability(33)
+	CAP_SYSLOG             = Capability(34)
+	CAP_WAKE_ALARM         = Capability(35)
+	CAP_BLOCK_SUSPEND      = Capability(36)
+	CAP_AUDIT_READ         = Capability(37)
+	CAP_PERFMON            = Capability(38)
+	CAP_BPF                = Capability(39)
+	CAP_CHECKPOINT_RESTORE = Capability(40)
+)
+
+var capNames = []string{
+	CAP_CHOWN:              "CAP_CHOWN",
+	CAP_DAC_OVERRIDE:       "CAP_DAC_OVERRIDE",
+	CAP_DAC_READ_SEARCH:    "CAP_DAC_READ_SEARCH",
+	CAP_FOWNER:             "CAP_FOWNER",
+	CAP_FSETID:             "CAP_FSETID",
+	CAP_KILL:               "CAP_KILL",
+	CAP_SETGID:             "CAP_SETGID",
+	CAP_SETUID:             "CAP_SETUID",
+	CAP_SETPCAP:            "CAP_SETPCAP",
+	CAP_LINUX_IMMUTABLE:    "CAP_LINUX_IMMUTABLE",
+	CAP_NET_BIND_SERVICE:   "CAP_NET_BIND_SERVICE",
+	CAP_NET_BROADCAST:      "CAP_NET_BROADCAST",
+	CAP_NET_ADMIN:          "CAP_NET_ADMIN",
+	CAP_NET_RAW:            "CAP_NET_RAW",
+	CAP_IPC_LOCK:           "CAP_IPC_LOCK",
+	CAP_IPC_OWNER:          "CAP_IPC_OWNER",
+	CAP_SYS_MODULE:         "CAP_SYS_MODULE",
+	CAP_SYS_RAWIO:          "CAP_SYS_RAWIO",
+	CAP_SYS_CHROOT:         "CAP_SYS_CHROOT",
+	CAP_SYS_PTRACE:         "CAP_SYS_PTRACE",
+	CAP_SYS_PACCT:          "CAP_SYS_PACCT",
+	CAP_SYS_ADMIN:          "CAP_SYS_ADMIN",
+	CAP_SYS_BOOT:           "CAP_SYS_BOOT",
+	CAP_SYS_NICE:           "CAP_SYS_NICE",
+	CAP_SYS_RESOURCE:       "CAP_SYS_RESOURCE",
+	CAP_SYS_TIME:           "CAP_SYS_TIME",
+	CAP_SYS_TTY_CONFIG:     "CAP_SYS_TTY_CONFIG",
+	CAP_MKNOD:              "CAP_MKNOD",
+	CAP_LEASE:              "CAP_LEASE",
+	CAP_AUDIT_WRITE:        "CAP_AUDIT_WRITE",
+	CAP_AUDIT_CONTROL:      "CAP_AUDIT_CONTROL",
+	CAP_SETFCAP:            "CAP_SETFCAP",
+	CAP_MAC_OVERRIDE:       "CAP_MAC_OVERRIDE",
+	CAP_MAC_ADMIN:          "CAP_MAC_ADMIN",
+	CAP_SYSLOG:             "CAP_SYSLOG",
+	CAP_WAKE_ALARM:         "CAP_WAKE_ALARM",
+	CAP_BLOCK_SUSPEND:      "CAP_BLOCK_SUSPEND",
+	CAP_AUDIT_READ:         "CAP_AUDIT_READ",
+	CAP_PERFMON:            "CAP_PERFMON",
+	CAP_BPF:                "CAP_BPF",
+	CAP_CHECKPOINT_RESTORE: "CAP_CHECKPOINT_RESTORE",
+}
+
+// String returns the name of c, e.g., "CAP_NET_ADMIN".
+func (c Capability) String() string {
+	if c >= 0 && int(c) < len(capNames) {
+		return capNames[c]
+	}
+	return fmt.Sprintf("Capability(%d)", int(c))
+}
+
+// A CapSet is a set of capabilities, as found in the Cap* lines of
+// /proc/PID/status.  Bit N of a CapSet is set if Capability(N) is in the set.
+// CapSet is only available on linux.
+type CapSet uint64
+
+// NewCapSet returns a CapSet containing caps.
+func NewCapSet(caps ...Capability) CapSet {
+	var s CapSet
+	for _, c := range caps {
+		s = s.Add(c)
+	}
+	return s
+}
+
+// Has returns true if c is in s.
+func (s CapSet) Has(c Capability) bool {
+	return c >= 0 && c < 64 && s&(1<<uint(c)) != 0
+}
+
+// Add returns s with c added.
+func (s CapSet) Add(c Capability) CapSet {
+	if c < 0 || c >= 64 {
+		return s
+	}
+	return s | 1<<uint(c)
+}
+
+// Remove returns s with c removed.
+func (s CapSet) Remove(c Capability) CapSet {
+	if c < 0 || c >= 64 {
+		return s
+	}
+	return s &^ (1 << uint(c))
+}
+
+// Union returns the capabilities in either s or o.
+func (s CapSet) Union(o CapSet) CapSet {
+	return s | o
+}
+
+// Intersect returns the capabilities in both s and o.
+func (s CapSet) Intersect(o CapSet) CapSet {
+	return s & o
+}
+
+// Difference returns the capabilities in s that are not in o.
+func (s CapSet) Difference(o CapSet) CapSet {
+	return s &^ o
+}
+
+// Capabilities returns the capabilities in s in numeric order.
+func (s CapSet) Capabilities() []Capability {
+	caps := make([]Capability, 0, bits.OnesCount64(uint64(s)))
+	for c := Capability(0); c < 64; c++ {
+		if s.Has(c) {
+			caps = append(caps, c)
+		}
+	}
+	return caps
+}
+
+// String returns the names of the capabilities in s separated by commas, e.g.,
+// "CAP_KILL,CAP_NET_ADMIN".  The empty set is returned as "".
+func (s CapSet) String() string {
+	var names []string
+	for _, c := range s.Capabilities() {
+		names = append(names, c.String())
+	}
+	return strings.Join(names, ",")
+}
+
+// Capabilities are the capability sets of a process, as described in
+// capabilities(7).
+// Capabilities is only available on linux.
+type Capabilities struct {
+	Inheritable CapSet // CapInh
+	Permitted   CapSet // CapPrm
+	Effective   CapSet // CapEff
+	Bounding    CapSet // CapBnd
+	Ambient     CapSet // CapAmb, not reported by kernels before 4.3
+}
+
+// Capabilities returns the capability sets of p from /proc/PID/status.
+// Capabilities is only available on linux.
+func (p *Process) Capabilities() (*Capabilities, error) {
+	status, err := p.StatusMap(true)
+	if err != nil {
+		return nil, err
+	}
+	caps := &Capabilities{}
+	for _, c := range []struct {
+		name string
+		set  *CapSet
+	}{
+		{"CapInh", &caps.Inheritable},
+		{"CapPrm", &caps.Permitted},
+		{"CapEff", &caps.Effective},
+		{"CapBnd", &caps.Bounding},
+		{"CapAmb", &caps.Ambient},
+	} {
+		v, ok := status[c.name]
+		if !ok {
+			continue
+		}
+		set, err := v.AsUnsignedHex()
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s: %v", c.name, err)
+		}
+		*c.set = CapSet(set)
+	}
+	return caps, nil
+}
@@ -0,0 +1,104 @@
+//go:build linux
+
+package ps
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestCapSet(t *testing.T) {
+	s := NewCapSet(CAP_NET_ADMIN, CAP_KILL, CAP_CHECKPOINT_RESTORE)
+	if s != 1<<5|1<<12|1<<40 {
+		t.Errorf("Got set %#x", uint64(s))
+	}
+	if !s.Has(CAP_KILL) || s.Has(CAP_SYS_ADMIN) || s.Has(-1) || s.Has(64) {
+		t.Errorf("Has is wrong for %#x", uint64(s))
+	}
+	if got, want := s.String(), "CAP_KILL,CAP_NET_ADMIN,CAP_CHECKPOINT_RESTORE"; got != want {
+		t.Errorf("Got %q, want %q", got, want)
+	}
+	if got := fmt.Sprint(s.Capabilities()); got != "[CAP_KILL CAP_NET_ADMIN CAP_CHECKPOINT_RESTORE]" {
+		t.Errorf("Got capabilities %s", got)
+	}
+	if got, want := CapSet(1<<63|1).String(), "CAP_CHOWN,Capability(63)"; got != want {
+		t.Errorf("Got %q, want %q", got, want)
+	}
+	if s := CapSet(0).String(); s != "" {
+		t.Errorf("Got %q for the empty set", s)
+	}
+
+	o := NewCapSet(CAP_KILL, CAP_SYS_PTRACE)
+	for _, tt := range []struct {
+		name      string
+		got, want CapSet
+	}{
+		{"Add", s.Add(CAP_SYS_PTRACE), NewCapSet(CAP_NET_ADMIN, CAP_KILL, CAP_CHECKPOINT_RESTORE, CAP_SYS_PTRACE)},
+		{"Add invalid", s.Add(64), s},
+		{"Remove", s.Remove(CAP_KILL), NewCapSet(CAP_NET_ADMIN, CAP_CHECKPOINT_RESTORE)},
+		{"Union", s.Union(o), NewCapSet(CAP_NET_ADMIN, CAP_KILL, CAP_CHECKPOINT_RESTORE, CAP_SYS_PTRACE)},
+		{"Intersect", s.Intersect(o), NewCapSet(CAP_KILL)},
+		{"Difference", s.Difference(o), NewCapSet(CAP_NET_ADMIN, CAP_CHECKPOINT_RESTORE)},
+	} {
+		if tt.got != tt.want {
+			t.Errorf("%s: got %v, want %v", tt.name, tt.got, tt.want)
+		}
+	}
+}
+
+func TestCapabilities(t *testing.T) {
+	fsys := testProcFS()
+	fsys.fs["1/status"].Data = append(fsys.fs["1/status"].Data,
+		"CapInh:\t0000000000000000\nCapPrm:\t000001ffffffffff\nCapEff:\t000001ffffffffff\nCapBnd:\tffffffffffffffff\nCapAmb:\t0000000000000000\n"...)
+	fsys.fs["100/status"].Data = append(fsys.fs["100/status"].Data,
+		"CapInh:\t0000000000000000\nCapPrm:\t0000000002000000\nCapEff:\t0000000002000000\nCapBnd:\t0000000002000000\n"...)
+	fsys.fs["3/status"].Data = append(fsys.fs["3/status"].Data, "CapEff:\tzzz\n"...)
+	src := NewSourceFS(fsys)
+
+	p, err := src.ProcessByPid(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	caps, err := p.Capabilities()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := Capabilities{
+		Permitted: 1<<41 - 1,
+		Effective: 1<<41 - 1,
+		Bounding:  ^CapSet(0),
+	}
+	if *caps != want {
+		t.Errorf("Got %+v, want %+v", *caps, want)
+	}
+
+	p, err = src.ProcessByPid(100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	caps, err = p.Capabilities()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s := caps.Effective.String(); s != "CAP_SYS_TIME" || caps.Ambient != 0 {
+		t.Errorf("Got %+v", *caps)
+	}
+
+	p, err = src.ProcessByPid(3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := p.Capabilities(); err == nil {
+		t.Errorf("Did not get an error for an invalid capability set")
+	}
+}
+
+func TestCapabilitiesLive(t *testing.T) {
+	caps, err := (&Process{ID: mypid}).Capabilities()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if caps.Effective.Difference(caps.Permitted) != 0 {
+		t.Errorf("Effective %v is not a subset of permitted %v", caps.Effective, caps.Permitted)
+	}
+}
@@ -0,0 +1,548 @@
+//go:build linux
+
+package ps
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"io"
+	"io/fs"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// captureFiles are the files captured from each process directory.
+var captureFiles = []string{
+	"stat",
+	"status",
+	"cmdline",
+	"environ",
+	"maps",
+	"smaps_rollup",
+	"cgroup",
+	"io",
+	"limits",
+}
+
+// captureNetFiles are the files captured from the net directory of each
+// process.  They describe the network namespace of the process rather than the
+// process itself.
+var captureNetFiles = []string{
+	"tcp",
+	"tcp6",
+	"udp",
+	"udp6",
+	"unix",
+}
+
+// captureNetDir is the directory of a capture holding the network tables of
+// each network namespace, by inode, e.g., netns/4026531840/tcp.
+const captureNetDir = "netns"
+
+// captureTaskFiles are the files captured from the directory of each task in
+// PID/task.
+var captureTaskFiles = []string{
+	"stat",
+	"status",
+}
+
+// captureRootFiles are the files captured from the root of the procfs.
+var captureRootFiles = []string{
+	"stat",
+	"uptime",
+}
+
+// CaptureOptions are the options to Capture.
+type CaptureOptions struct {
+	// NoEnviron causes the environment of processes not to be captured.
+	NoEnviron bool
+
+	// Redact, if not nil, is called with the name of each environment
+	// variable captured.  The value of the variable is replaced with
+	// "REDACTED" if Redact returns true.
+	Redact func(name string) bool
+}
+
+// Redacted is the value given to redacted environment variables by Capture.
+const Redacted = "REDACTED"
+
+// Capture writes a tar archive of the processes in s to w.  The archive
+// contains the files of each process read by the methods of Process, such as
+// stat, status, cmdline and environ, the stat and status of each task, and the
+// targets of the exe, cwd, root, ns and fd links.  The network tables, such as
+// net/tcp, are captured once for each network namespace.  Files that cannot be
+// read, such as the environment of a process owned by a different user, are
+// not included.  The ownership of each process is recorded in the archive.
+//
+// The archive can be turned back into a Source with LoadCapture.  opts may be
+// nil.
+func (s *Source) Capture(w io.Writer, opts *CaptureOptions) error {
+	if opts == nil {
+		opts = &CaptureOptions{}
+	}
+	procs, err := s.Processes(true)
+	if err != nil {
+		return err
+	}
+	c := &capturer{
+		src:   s,
+		tw:    tar.NewWriter(w),
+		opts:  opts,
+		now:   time.Now(),
+		netns: map[uint64]bool{},
+	}
+	for _, name := range captureRootFiles {
+		data, err := s.readFile(path.Join(s.root, name))
+		if err != nil {
+			continue
+		}
+		if err := c.writeFile(name, data); err != nil {
+			return err
+		}
+	}
+	for _, p := range procs {
+		if err := c.captureProcess(p); err != nil {
+			return err
+		}
+	}
+	return c.tw.Close()
+}
+
+// Capture writes a tar archive of the processes in /proc to w.  See
+// Source.Capture for details.
+func Capture(w io.Writer, opts *CaptureOptions) error {
+	return defaultSource.Capture(w, opts)
+}
+
+type capturer struct {
+	src   *Source
+	tw    *tar.Writer
+	opts  *CaptureOptions
+	now   time.Time
+	netns map[uint64]bool // The network namespaces captured
+}
+
+func (c *capturer) captureProcess(p *Process) error {
+	dir := path.Base(p.dirname())
+	err := c.tw.WriteHeader(&tar.Header{
+		Typeflag: tar.TypeDir,
+		Name:     dir + "/",
+		Mode:     int64(p.sysstat.Mode & 0777),
+		Uid:      int(p.sysstat.Uid),
+		Gid:      int(p.sysstat.Gid),
+		ModTime:  c.now,
+	})
+	if err != nil {
+		return err
+	}
+	for _, name := range captureFiles {
+		if name == "environ" && c.opts.NoEnviron {
+			continue
+		}
+		data, err := c.src.readFile(p.dirname() + "/" + name)
+		if err != nil {
+			continue
+		}
+		if name == "environ" && c.opts.Redact != nil {
+			data = redact(data, c.opts.Redact)
+		}
+		if err := c.writeFile(dir+"/"+name, data); err != nil {
+			return err
+		}
+	}
+	for _, name := range []string{"exe", "cwd", "root"} {
+		if target, err := c.src.readlink(p.dirname() + "/" + name); err == nil {
+			if err := c.writeLink(dir+"/"+name, target); err != nil {
+				return err
+			}
+		}
+	}
+	if names, err := c.src.readDirNames(p.dirname() + "/ns"); err == nil {
+		for _, name := range names {
+			target, err := c.src.readlink(p.dirname() + "/ns/" + name)
+			if err != nil {
+				continue
+			}
+			if err := c.writeLink(dir+"/ns/"+name, target); err != nil {
+				return err
+			}
+		}
+	}
+	if err := c.captureNet(p, dir); err != nil {
+		return err
+	}
+	if err := c.captureTasks(p, dir); err != nil {
+		return err
+	}
+	fds, err := c.src.readDirNames(p.dirname() + "/fd")
+	if err != nil {
+		return nil
+	}
+	for _, fd := range fds {
+		target, err := c.src.readlink(p.dirname() + "/fd/" + fd)
+		if err != nil {
+			continue
+		}
+		if err := c.writeLink(dir+"/fd/"+fd, target); err != nil {
+			return err
+		}
+		data, err := c.src.readFile(p.dirname() + "/fdinfo/" + fd)
+		if err != nil {
+			continue
+		}
+		if err := c.writeFile(dir+"/fdinfo/"+fd, data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// captureNet captures the network tables of p into captureNetDir, unless the
+// network namespace of p has already been captured.  The tables are captured
+// into dir/net if the namespace of p cannot be read.
+func (c *capturer) captureNet(p *Process, dir string) error {
+	target, err := c.src.readlink(p.dirname() + "/ns/net")
+	ns, nerr := parseNSLink("net", target)
+	switch {
+	case err != nil || nerr != nil:
+		dir += "/net"
+	case c.netns[ns]:
+		return nil
+	default:
+		c.netns[ns] = true
+		dir = captureNetDir + "/" + strconv.FormatUint(ns, 10)
+	}
+	for _, name := range captureNetFiles {
+		data, err := c.src.readFile(p.dirname() + "/net/" + name)
+		if err != nil {
+			continue
+		}
+		if err := c.writeFile(dir+"/"+name, data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// captureTasks captures the tasks of p into dir/task.  Tasks are owned by the
+// owner of p.
+func (c *capturer) captureTasks(p *Process, dir string) error {
+	tids, err := c.src.readDirNames(p.dirname() + "/task")
+	if err != nil {
+		return nil
+	}
+	for _, tid := range tids {
+		err := c.tw.WriteHeader(&tar.Header{
+			Typeflag: tar.TypeDir,
+			Name:     dir + "/task/" + tid + "/",
+			Mode:     int64(p.sysstat.Mode & 0777),
+			Uid:      int(p.sysstat.Uid),
+			Gid:      int(p.sysstat.Gid),
+			ModTime:  c.now,
+		})
+		if err != nil {
+			return err
+		}
+		for _, name := range captureTaskFiles {
+			data, err := c.src.readFile(p.dirname() + "/task/" + tid + "/" + name)
+			if err != nil {
+				continue
+			}
+			if err := c.writeFile(dir+"/task/"+tid+"/"+name, data); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (c *capturer) writeFile(name string, data []byte) error {
+	err := c.tw.WriteHeader(&tar.Header{
+		Typeflag: tar.TypeReg,
+		Name:     name,
+		Mode:     0444,
+		Size:     int64(len(data)),
+		ModTime:  c.now,
+	})
+	if err != nil {
+		return err
+	}
+	_, err = c.tw.Write(data)
+	return err
+}
+
+func (c *capturer) writeLink(name, target string) error {
+	return c.tw.WriteHeader(&tar.Header{
+		Typeflag: tar.TypeSymlink,
+		Name:     name,
+		Linkname: target,
+		Mode:     0777,
+		ModTime:  c.now,
+	})
+}
+
+// redact returns the NUL separated environment data with the values of the
+// variables selected by f replaced by Redacted.
+func redact(data []byte, f func(string) bool) []byte {
+	vars := bytes.Split(data, []byte{0})
+	for i, v := range vars {
+		x := bytes.IndexByte(v, '=')
+		if x < 0 || !f(string(v[:x])) {
+			continue
+		}
+		vars[i] = append(v[:x+1:x+1], Redacted...)
+	}
+	return bytes.Join(vars, []byte{0})
+}
+
+// LoadCapture reads an archive written by Capture, optionally compressed with
+// gzip, and returns a Source that reads from it.  The archive is held in
+// memory.
+func LoadCapture(r io.Reader) (*Source, error) {
+	br := bufio.NewReader(r)
+	if magic, _ := br.Peek(2); len(magic) == 2 && magic[0] == 0x1f && magic[1] == 0x8b {
+		zr, err := gzip.NewReader(br)
+		if err != nil {
+			return nil, err
+		}
+		defer zr.Close()
+		r = zr
+	} else {
+		r = br
+	}
+	fsys := &captureFS{files: map[string]*captureFile{}}
+	tr := tar.NewReader(r)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		name := path.Clean(strings.TrimPrefix(hdr.Name, "/"))
+		mode := fs.FileMode(hdr.Mode).Perm()
+		switch hdr.Typeflag {
+		case tar.TypeDir:
+			fsys.files[name] = &captureFile{
+				name:    path.Base(name),
+				mode:    fs.ModeDir | mode,
+				modTime: hdr.ModTime,
+				sys: &syscall.Stat_t{
+					Mode: syscall.S_IFDIR | uint32(mode),
+					Uid:  uint32(hdr.Uid),
+					Gid:  uint32(hdr.Gid),
+				},
+			}
+		case tar.TypeSymlink:
+			fsys.files[name] = &captureFile{
+				name:    path.Base(name),
+				data:    []byte(hdr.Linkname),
+				mode:    fs.ModeSymlink | mode,
+				modTime: hdr.ModTime,
+			}
+		case tar.TypeReg:
+			data, err := io.ReadAll(tr)
+			if err != nil {
+				return nil, err
+			}
+			fsys.files[name] = &captureFile{
+				name:    path.Base(name),
+				data:    data,
+				mode:    mode,
+				modTime: hdr.ModTime,
+			}
+		}
+	}
+	fsys.linkNet()
+	fsys.index()
+	return NewSourceFS(fsys), nil
+}
+
+// A captureFS is the in-memory file system of a loaded capture.  Directories
+// that are not in the archive are implied by the names of the files in them.
+// The target of a symbolic link is its data.
+type captureFS struct {
+	files map[string]*captureFile
+	dirs  map[string][]string // The sorted names in each directory
+}
+
+// linkNet makes the network tables captured for each network namespace appear
+// in the net directory of each process in the namespace.  The tables captured
+// in the directory of a process are kept.
+func (fsys *captureFS) linkNet() {
+	for name, f := range fsys.files {
+		if !strings.HasSuffix(name, "/ns/net") || f.mode&fs.ModeSymlink == 0 {
+			continue
+		}
+		ns, err := parseNSLink("net", string(f.data))
+		if err != nil {
+			continue
+		}
+		pdir := path.Dir(path.Dir(name))
+		for _, table := range captureNetFiles {
+			src := fsys.files[path.Join(captureNetDir, strconv.FormatUint(ns, 10), table)]
+			dst := path.Join(pdir, "net", table)
+			if src != nil && fsys.files[dst] == nil {
+				fsys.files[dst] = src
+			}
+		}
+	}
+}
+
+// index fills in fsys.dirs, adding the directories implied by the names of
+// the files in fsys.
+func (fsys *captureFS) index() {
+	names := map[string]map[string]bool{".": {}}
+	for name := range fsys.files {
+		for name != "." {
+			dir := path.Dir(name)
+			if names[dir] == nil {
+				names[dir] = map[string]bool{}
+			}
+			names[dir][path.Base(name)] = true
+			name = dir
+		}
+	}
+	fsys.dirs = map[string][]string{}
+	for dir, set := range names {
+		if fsys.files[dir] == nil {
+			fsys.files[dir] = &captureFile{name: path.Base(dir), mode: fs.ModeDir | 0555}
+		}
+		list := make([]string, 0, len(set))
+		for name := range set {
+			list = append(list, name)
+		}
+		sort.Strings(list)
+		fsys.dirs[dir] = list
+	}
+}
+
+func (fsys *captureFS) lookup(op, name string) (*captureFile, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: op, Path: name, Err: fs.ErrInvalid}
+	}
+	f := fsys.files[name]
+	if f == nil {
+		return nil, &fs.PathError{Op: op, Path: name, Err: fs.ErrNotExist}
+	}
+	return f, nil
+}
+
+func (fsys *captureFS) Open(name string) (fs.File, error) {
+	f, err := fsys.lookup("open", name)
+	if err != nil {
+		return nil, err
+	}
+	of := &openCaptureFile{captureFile: f}
+	if f.IsDir() {
+		of.entries, _ = fsys.ReadDir(name)
+	} else {
+		of.r = bytes.NewReader(f.data)
+	}
+	return of, nil
+}
+
+func (fsys *captureFS) Stat(name string) (fs.FileInfo, error) {
+	return fsys.lookup("stat", name)
+}
+
+func (fsys *captureFS) ReadFile(name string) ([]byte, error) {
+	f, err := fsys.lookup("read", name)
+	if err != nil {
+		return nil, err
+	}
+	if f.IsDir() {
+		return nil, &fs.PathError{Op: "read", Path: name, Err: fs.ErrInvalid}
+	}
+	return append([]byte(nil), f.data...), nil
+}
+
+func (fsys *captureFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	f, err := fsys.lookup("readdir", name)
+	if err != nil {
+		return nil, err
+	}
+	if !f.IsDir() {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: fs.ErrInvalid}
+	}
+	names := fsys.dirs[name]
+	entries := make([]fs.DirEntry, len(names))
+	for i, n := range names {
+		entries[i] = fsys.files[path.Join(name, n)]
+	}
+	return entries, nil
+}
+
+func (fsys *captureFS) ReadLink(name string) (string, error) {
+	f, err := fsys.lookup("readlink", name)
+	if err != nil {
+		return "", err
+	}
+	if f.mode&fs.ModeSymlink == 0 {
+		return "", &fs.PathError{Op: "readlink", Path: name, Err: fs.ErrInvalid}
+	}
+	return string(f.data), nil
+}
+
+// A captureFile is a file, directory or symbolic link in a captureFS.  It is
+// both the fs.FileInfo and the fs.DirEntry of the file.
+type captureFile struct {
+	name    string
+	data    []byte
+	mode    fs.FileMode
+	modTime time.Time
+	sys     interface{}
+}
+
+func (f *captureFile) Name() string               { return f.name }
+func (f *captureFile) Size() int64                { return int64(len(f.data)) }
+func (f *captureFile) Mode() fs.FileMode          { return f.mode }
+func (f *captureFile) ModTime() time.Time         { return f.modTime }
+func (f *captureFile) IsDir() bool                { return f.mode.IsDir() }
+func (f *captureFile) Sys() interface{}           { return f.sys }
+func (f *captureFile) Type() fs.FileMode          { return f.mode.Type() }
+func (f *captureFile) Info() (fs.FileInfo, error) { return f, nil }
+
+// An openCaptureFile is an open captureFile.
+type openCaptureFile struct {
+	*captureFile
+	r       *bytes.Reader // The contents of a file, nil for a directory
+	entries []fs.DirEntry // The unread entries of a directory
+}
+
+func (f *openCaptureFile) Stat() (fs.FileInfo, error) { return f.captureFile, nil }
+func (f *openCaptureFile) Close() error               { return nil }
+
+func (f *openCaptureFile) Read(b []byte) (int, error) {
+	if f.r == nil {
+		return 0, &fs.PathError{Op: "read", Path: f.name, Err: fs.ErrInvalid}
+	}
+	return f.r.Read(b)
+}
+
+func (f *openCaptureFile) ReadDir(n int) ([]fs.DirEntry, error) {
+	if f.r != nil {
+		return nil, &fs.PathError{Op: "readdir", Path: f.name, Err: fs.ErrInvalid}
+	}
+	if n <= 0 {
+		entries := f.entries
+		f.entries = nil
+		return entries, nil
+	}
+	if len(f.entries) == 0 {
+		return nil, io.EOF
+	}
+	if n > len(f.entries) {
+		n = len(f.entries)
+	}
+	entries := f.entries[:n]
+	f.entries = f.entries[n:]
+	return entries, nil
+}
@@ -0,0 +1,191 @@
+//go:build linux
+
+package ps
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestCapture(t *testing.T) {
+	var buf bytes.Buffer
+	opts := &CaptureOptions{
+		Redact: func(name string) bool { return name == "HOME" },
+	}
+	fsys := testNetFS()
+	fsys.fs["1/limits"] = &fstest.MapFile{Data: []byte(testLimits)}
+	delete(fsys.fs, "100/ns/net")
+	fsys.fs["2/ns/net"] = testLink("net:[4026531840]")
+	if err := NewSourceFS(fsys).Capture(&buf, opts); err != nil {
+		t.Fatal(err)
+	}
+	// The network tables are captured once for each network namespace,
+	// or with the process if its namespace is not known.
+	var tables []string
+	tr := tar.NewReader(bytes.NewReader(buf.Bytes()))
+	for {
+		hdr, err := tr.Next()
+		if err != nil {
+			break
+		}
+		if strings.HasSuffix(hdr.Name, "/tcp") {
+			tables = append(tables, hdr.Name)
+		}
+	}
+	if want := []string{"netns/4026531840/tcp", "100/net/tcp"}; strings.Join(tables, " ") != strings.Join(want, " ") {
+		t.Errorf("Got tcp tables %q, want %q", tables, want)
+	}
+	src, err := LoadCapture(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := fstest.TestFS(src.fsys, "1/stat", "1/fd/3", "1/net/tcp", "100/status", "100/net/tcp", "2/net/unix", "uptime"); err != nil {
+		t.Fatal(err)
+	}
+	procs, err := src.Processes(true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(procs) != 5 {
+		t.Errorf("Got %d processes, want 5", len(procs))
+	}
+
+	p, err := src.ProcessByPid(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if path, err := p.Path(); err != nil || path != "/usr/lib/systemd/systemd" {
+		t.Errorf("Got path %q, %v", path, err)
+	}
+	env, err := p.Environ()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if env["HOME"] != Redacted || env["TERM"] != "linux" {
+		t.Errorf("Got environment %q", env)
+	}
+	argv, err := p.Argv()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(argv) != 2 || argv[0] != "/sbin/init" || argv[1] != "splash" {
+		t.Errorf("Got argv %q", argv)
+	}
+	if target, err := src.readlink("1/fd/3"); err != nil || target != "socket:[12345]" {
+		t.Errorf("Got fd 3 %q, %v", target, err)
+	}
+	conns, err := p.Connections()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(conns) != 6 || conns[0].String() != "tcp 0.0.0.0:22->0.0.0.0:0 LISTEN" {
+		t.Errorf("Got connections %v", conns)
+	}
+	limits, err := p.Limits()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s := limits[RLIMIT_STACK].String(); s != "8388608 unlimited" {
+		t.Errorf("Got stack limit %q", s)
+	}
+	threads, err := p.Threads()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(threads) != 2 || threads[1].ID != 7 {
+		t.Fatalf("Got %d threads, want 1 and 7", len(threads))
+	}
+	if s, err := threads[1].Stat(); err != nil || s.Comm != "sd-worker" {
+		t.Errorf("Got thread 7 stat %v, %v", s, err)
+	}
+	if tasks, err := src.Tasks(true); err != nil || len(tasks) != 2 {
+		t.Errorf("Got %d tasks, %v, want 2", len(tasks), err)
+	}
+	if data, err := src.readFile("uptime"); err != nil || string(data) != "1000.00 2000.00\n" {
+		t.Errorf("Got uptime %q, %v", data, err)
+	}
+
+	p, err = src.ProcessByPid(100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if uid, err := p.Uid(); err != nil || uid != 102 {
+		t.Errorf("Got uid %d, %v, want 102", uid, err)
+	}
+	// The environment of 100 could not be read and was not captured.
+	if _, err := p.Environ(); err == nil {
+		t.Errorf("Got environment for 100")
+	}
+	if cmd, err := p.Command(); err != nil || cmd != "systemd-timesyn" {
+		t.Errorf("Got command %q, %v", cmd, err)
+	}
+	if conns, err := p.Connections(); err != nil || len(conns) != 1 || conns[0].Inode != 12346 {
+		t.Errorf("Got connections %v, %v", conns, err)
+	}
+}
+
+func TestCaptureGzip(t *testing.T) {
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if err := NewSourceFS(testProcFS()).Capture(zw, &CaptureOptions{NoEnviron: true}); err != nil {
+		t.Fatal(err)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	src, err := LoadCapture(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p, err := src.ProcessByPid(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s, err := p.Stat(); err != nil || s.Comm != "systemd" {
+		t.Errorf("Got stat %v, %v", s, err)
+	}
+	if _, err := p.Environ(); err == nil {
+		t.Errorf("Environment was captured")
+	}
+}
+
+func TestCaptureLive(t *testing.T) {
+	var buf bytes.Buffer
+	if err := Capture(&buf, nil); err != nil {
+		t.Fatal(err)
+	}
+	src, err := LoadCapture(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p, err := src.ProcessByPid(mypid)
+	if err != nil {
+		t.Fatal(err)
+	}
+	argv, err := p.Argv()
+	if err != nil {
+		t.Fatal(err)
+	}
+	live, err := (&Process{ID: mypid}).Argv()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(argv) != len(live) || argv[0] != live[0] {
+		t.Errorf("Got argv %q, want %q", argv, live)
+	}
+	ns, err := p.Namespaces()
+	if err != nil {
+		t.Fatal(err)
+	}
+	liveNS, err := (&Process{ID: mypid}).Namespaces()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *ns != *liveNS {
+		t.Errorf("Got namespaces %+v, want %+v", *ns, *liveNS)
+	}
+}
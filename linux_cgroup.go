@@ -0,0 +1,199 @@
+//go:build linux
+
+package ps
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// A Cgroup is the membership of a process in a single cgroup hierarchy, as
+// found in /proc/PID/cgroup.
+// Cgroup is only available on linux.
+type Cgroup struct {
+	ID          int      // The hierarchy ID, 0 for the cgroup v2 hierarchy
+	Controllers []string // The controllers bound to a v1 hierarchy, e.g., "cpu" or "name=systemd"
+	Path        string   // The path of the cgroup relative to the root of the hierarchy
+}
+
+// IsV2 returns true if c is a member of the unified (cgroup v2) hierarchy.
+func (c *Cgroup) IsV2() bool {
+	return c.ID == 0 && len(c.Controllers) == 0
+}
+
+// Has returns true if controller is bound to the hierarchy of c.
+func (c *Cgroup) Has(controller string) bool {
+	for _, cc := range c.Controllers {
+		if cc == controller {
+			return true
+		}
+	}
+	return false
+}
+
+// Cgroups returns the cgroups p is a member of, one for each hierarchy.  On
+// a system using only cgroup v2 there is a single Cgroup.
+// Cgroups is only available on linux.
+func (p *Process) Cgroups() ([]Cgroup, error) {
+	data, err := p.source().readFile(p.dirname() + "/cgroup")
+	if err != nil {
+		return nil, fixError(err)
+	}
+	var cgroups []Cgroup
+	for _, line := range bytes.Split(data, []byte{'\n'}) {
+		if len(line) == 0 {
+			continue
+		}
+		c, err := parseCgroup(string(line))
+		if err != nil {
+			return nil, err
+		}
+		cgroups = append(cgroups, c)
+	}
+	return cgroups, nil
+}
+
+// parseCgroup parses a single line of /proc/PID/cgroup, which has the form
+// "ID:CONTROLLERS:PATH".
+func parseCgroup(line string) (Cgroup, error) {
+	f := strings.SplitN(line, ":", 3)
+	if len(f) != 3 {
+		return Cgroup{}, fmt.Errorf("invalid cgroup line %q", line)
+	}
+	id, err := strconv.Atoi(f[0])
+	if err != nil {
+		return Cgroup{}, fmt.Errorf("invalid cgroup line %q", line)
+	}
+	c := Cgroup{ID: id, Path: f[2]}
+	if f[1] != "" {
+		c.Controllers = strings.Split(f[1], ",")
+	}
+	return c, nil
+}
+
+// A Container identifies the container, Kubernetes pod and systemd unit a
+// process belongs to, as determined from the path of its cgroup.  Fields that
+// cannot be determined are empty.
+// Container is only available on linux.
+type Container struct {
+	Runtime string // "docker", "containerd", "cri-o" or "podman"
+	ID      string // The container ID
+	PodUID  string // The UID of the Kubernetes pod
+	Unit    string // The systemd unit, e.g., "sshd.service" or "session-2.scope"
+}
+
+// containerPrefixes map the prefixes of the systemd scopes created for
+// containers to their runtime.
+var containerPrefixes = []struct {
+	prefix  string
+	runtime string
+}{
+	{"docker-", "docker"},
+	{"cri-containerd-", "containerd"},
+	{"crio-conmon-", "cri-o"},
+	{"crio-", "cri-o"},
+	{"libpod-conmon-", "podman"},
+	{"libpod-", "podman"},
+}
+
+// unitSuffixes are the suffixes of the systemd units processes are placed in.
+var unitSuffixes = []string{".service", ".scope", ".socket", ".mount", ".swap"}
+
+// Container returns the container p belongs to.  The cgroup paths of p are
+// matched against the layouts used by systemd, docker, containerd, cri-o,
+// podman and the kubelet.  An empty Container is returned if p is not in a
+// recognized cgroup.
+// Container is only available on linux.
+func (p *Process) Container() (*Container, error) {
+	cgroups, err := p.Cgroups()
+	if err != nil {
+		return nil, err
+	}
+	// Prefer the unified hierarchy, then the systemd hierarchy, then any
+	// other hierarchy.
+	var paths []string
+	for _, c := range cgroups {
+		if c.IsV2() {
+			paths = append(paths, c.Path)
+		}
+	}
+	for _, c := range cgroups {
+		if c.Has("name=systemd") {
+			paths = append(paths, c.Path)
+		}
+	}
+	for _, c := range cgroups {
+		if !c.IsV2() && !c.Has("name=systemd") {
+			paths = append(paths, c.Path)
+		}
+	}
+	for _, path := range paths {
+		if c := parseContainer(path); *c != (Container{}) {
+			return c, nil
+		}
+	}
+	return &Container{}, nil
+}
+
+// parseContainer returns the container described by the cgroup path.
+func parseContainer(path string) *Container {
+	c := &Container{}
+	parent := ""
+	kubepods := false
+	for _, name := range strings.Split(path, "/") {
+		switch {
+		case name == "":
+			continue
+		case isContainerID(name):
+			// cgroupfs layouts, e.g., /docker/ID or /kubepods/besteffort/podUID/ID
+			c.ID = name
+			if parent == "docker" {
+				c.Runtime = "docker"
+			}
+		case name == "kubepods":
+			kubepods = true
+		case kubepods && strings.HasPrefix(name, "pod"):
+			c.PodUID = name[len("pod"):]
+		case strings.HasPrefix(name, "kubepods") && strings.HasSuffix(name, ".slice"):
+			// The systemd layout, e.g.,
+			// /kubepods.slice/kubepods-burstable.slice/kubepods-burstable-podUID.slice
+			if x := strings.LastIndex(name, "-pod"); x >= 0 {
+				uid := strings.TrimSuffix(name[x+len("-pod"):], ".slice")
+				c.PodUID = strings.Replace(uid, "_", "-", -1)
+			}
+		default:
+			for _, suffix := range unitSuffixes {
+				if strings.HasSuffix(name, suffix) {
+					c.Unit = name
+					break
+				}
+			}
+			id := strings.TrimSuffix(name, ".scope")
+			for _, cp := range containerPrefixes {
+				if strings.HasPrefix(id, cp.prefix) && isContainerID(id[len(cp.prefix):]) {
+					c.Runtime = cp.runtime
+					c.ID = id[len(cp.prefix):]
+					break
+				}
+			}
+		}
+		parent = name
+	}
+	return c
+}
+
+// isContainerID returns true if s looks like a container ID, which is 64
+// hexadecimal digits.
+func isContainerID(s string) bool {
+	if len(s) != 64 {
+		return false
+	}
+	for _, c := range s {
+		if (c < '0' || c > '9') && (c < 'a' || c > 'f') {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,162 @@
+//go:build linux
+
+package ps
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+const (
+	testContainerID = "4f1c8b2de3a9c0f17b6e5d4c3b2a190817263544536271809a8b7c6d5e4f3a2b"
+	testPodUID      = "6b1e3e8a-2c4d-4f6a-9b8c-0d1e2f3a4b5c"
+)
+
+const testCgroupV1 = `12:pids:/system.slice/sshd.service
+11:memory:/system.slice/sshd.service
+3:cpu,cpuacct:/system.slice/sshd.service
+1:name=systemd:/system.slice/sshd.service
+0::/system.slice/sshd.service
+`
+
+func TestCgroups(t *testing.T) {
+	fsys := testProcFS()
+	fsys.fs["1/cgroup"] = &fstest.MapFile{Data: []byte(testCgroupV1)}
+	fsys.fs["3/cgroup"] = &fstest.MapFile{Data: []byte("0::/\n")}
+	src := NewSourceFS(fsys)
+
+	p, err := src.ProcessByPid(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cgroups, err := p.Cgroups()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, c := range cgroups {
+		got = append(got, fmt.Sprintf("%d %s %s %v", c.ID, strings.Join(c.Controllers, ","), c.Path, c.IsV2()))
+	}
+	want := []string{
+		"12 pids /system.slice/sshd.service false",
+		"11 memory /system.slice/sshd.service false",
+		"3 cpu,cpuacct /system.slice/sshd.service false",
+		"1 name=systemd /system.slice/sshd.service false",
+		"0  /system.slice/sshd.service true",
+	}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("Got cgroups %q, want %q", got, want)
+	}
+	if !cgroups[2].Has("cpuacct") || cgroups[2].Has("memory") {
+		t.Errorf("Has is wrong for %v", cgroups[2].Controllers)
+	}
+
+	p, err = src.ProcessByPid(3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cgroups, err = p.Cgroups()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cgroups) != 1 || !cgroups[0].IsV2() || cgroups[0].Path != "/" {
+		t.Errorf("Got cgroups %+v", cgroups)
+	}
+
+	fsys.fs["3/cgroup"] = &fstest.MapFile{Data: []byte("bad line\n")}
+	if _, err := p.Cgroups(); err == nil {
+		t.Errorf("Did not get an error for an invalid cgroup file")
+	}
+}
+
+func TestParseContainer(t *testing.T) {
+	podSlice := strings.Replace(testPodUID, "-", "_", -1)
+	for _, tt := range []struct {
+		path string
+		want Container
+	}{
+		{"/", Container{}},
+		{"/init.scope", Container{Unit: "init.scope"}},
+		{"/system.slice/sshd.service", Container{Unit: "sshd.service"}},
+		{"/user.slice/user-1000.slice/session-2.scope", Container{Unit: "session-2.scope"}},
+		{"/user.slice/user-1000.slice/user@1000.service/app.slice/app-gnome-firefox-1234.scope",
+			Container{Unit: "app-gnome-firefox-1234.scope"}},
+		{"/docker/" + testContainerID, Container{Runtime: "docker", ID: testContainerID}},
+		{"/system.slice/docker-" + testContainerID + ".scope",
+			Container{Runtime: "docker", ID: testContainerID, Unit: "docker-" + testContainerID + ".scope"}},
+		{"/machine.slice/libpod-" + testContainerID + ".scope/container",
+			Container{Runtime: "podman", ID: testContainerID, Unit: "libpod-" + testContainerID + ".scope"}},
+		{"/kubepods.slice/kubepods-burstable.slice/kubepods-burstable-pod" + podSlice + ".slice/cri-containerd-" + testContainerID + ".scope",
+			Container{Runtime: "containerd", ID: testContainerID, PodUID: testPodUID, Unit: "cri-containerd-" + testContainerID + ".scope"}},
+		{"/kubepods.slice/kubepods-pod" + podSlice + ".slice/crio-" + testContainerID + ".scope",
+			Container{Runtime: "cri-o", ID: testContainerID, PodUID: testPodUID, Unit: "crio-" + testContainerID + ".scope"}},
+		{"/kubepods.slice/kubepods-besteffort.slice/kubepods-besteffort-pod" + podSlice + ".slice",
+			Container{PodUID: testPodUID}},
+		{"/kubepods/besteffort/pod" + testPodUID + "/" + testContainerID,
+			Container{ID: testContainerID, PodUID: testPodUID}},
+		{"/kubepods/pod" + testPodUID + "/" + testContainerID,
+			Container{ID: testContainerID, PodUID: testPodUID}},
+		{"/system.slice/docker-1234.scope", Container{Unit: "docker-1234.scope"}},
+		{"/pod1234", Container{}},
+	} {
+		if got := parseContainer(tt.path); *got != tt.want {
+			t.Errorf("%s: got %+v, want %+v", tt.path, *got, tt.want)
+		}
+	}
+}
+
+func TestContainer(t *testing.T) {
+	fsys := testProcFS()
+	// A hybrid system where the unified hierarchy is not used.
+	fsys.fs["100/cgroup"] = &fstest.MapFile{Data: []byte(
+		"4:memory:/docker/" + testContainerID + "\n" +
+			"1:name=systemd:/docker/" + testContainerID + "\n" +
+			"0::/\n")}
+	fsys.fs["200/cgroup"] = &fstest.MapFile{Data: []byte("2:cpu:/\n1:name=systemd:/\n")}
+	src := NewSourceFS(fsys)
+
+	p, err := src.ProcessByPid(100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, err := p.Container()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := (Container{Runtime: "docker", ID: testContainerID}); *c != want {
+		t.Errorf("Got container %+v, want %+v", *c, want)
+	}
+
+	p, err = src.ProcessByPid(200)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, err = p.Container()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *c != (Container{}) {
+		t.Errorf("Got container %+v, want none", *c)
+	}
+}
+
+func TestCgroupsLive(t *testing.T) {
+	p := &Process{ID: mypid}
+	cgroups, err := p.Cgroups()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cgroups) == 0 {
+		t.Fatal("Got no cgroups")
+	}
+	for _, c := range cgroups {
+		if !strings.HasPrefix(c.Path, "/") {
+			t.Errorf("Got cgroup path %q", c.Path)
+		}
+	}
+	if _, err := p.Container(); err != nil {
+		t.Fatal(err)
+	}
+}
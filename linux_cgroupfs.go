@@ -0,0 +1,441 @@
+//go:build linux
+
+package ps
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"io/ioutil"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// A Cgroupfs is a cgroup file system that the resource usage of cgroups is
+// read from.  Both the unified (cgroup v2) layout, where the cgroup of a
+// process is at ROOT/PATH, and the v1 layout, where each controller has its
+// own hierarchy at ROOT/CONTROLLER/PATH, are supported.  On a hybrid system,
+// one using v1 controllers with the unified hierarchy mounted at ROOT/unified,
+// resource usage is read from the v1 hierarchies and pressure from the
+// unified hierarchy.
+// Cgroupfs is only available on linux.
+type Cgroupfs struct {
+	root string
+	fsys fs.FS
+}
+
+var defaultCgroupfs = NewCgroupfs("/sys/fs/cgroup")
+
+// NewCgroupfs returns a Cgroupfs that reads from the cgroup file system
+// mounted at root, normally /sys/fs/cgroup.
+func NewCgroupfs(root string) *Cgroupfs {
+	return &Cgroupfs{root: path.Clean(root)}
+}
+
+// NewCgroupfsFS returns a Cgroupfs that reads from fsys, which must be laid
+// out as the root of a cgroup file system (e.g., fsys contains the file
+// "system.slice/cgroup.controllers").  It is normally used to read a
+// synthetic tree, such as an fstest.MapFS.
+func NewCgroupfsFS(fsys fs.FS) *Cgroupfs {
+	return &Cgroupfs{root: ".", fsys: fsys}
+}
+
+// A CgroupUsage is the resource usage and limits of a cgroup.  Values whose
+// files cannot be read, normally because the controller is not enabled for
+// the cgroup, are left as zero.
+// CgroupUsage is only available on linux.
+type CgroupUsage struct {
+	Version int    // 1 or 2
+	Path    string // The path of the cgroup, e.g., "/system.slice/sshd.service"
+
+	MemoryCurrent int64            // Bytes of memory in use
+	MemoryMax     int64            // The memory limit in bytes, -1 if unlimited
+	MemoryStat    map[string]int64 // The contents of memory.stat
+
+	CPUTotal  time.Duration    // CPU time used
+	CPUUser   time.Duration    // CPU time used in user mode
+	CPUSystem time.Duration    // CPU time used in system mode
+	CPUStat   map[string]int64 // The contents of cpu.stat, e.g., nr_throttled
+
+	PidsCurrent int64 // The number of tasks in the cgroup
+	PidsMax     int64 // The limit on the number of tasks, -1 if unlimited
+
+	IO []CgroupIO // I/O by device
+
+	// Pressure is the pressure stall information of the cgroup, keyed by
+	// "cpu", "memory" and "io".  It is only available from the unified
+	// hierarchy.
+	Pressure map[string]Pressure
+}
+
+// A CgroupIO is the I/O done by a cgroup on a single device.
+// CgroupIO is only available on linux.
+type CgroupIO struct {
+	Major int
+	Minor int
+
+	// Stats are keyed by the names used in the cgroup v2 io.stat file:
+	// rbytes, wbytes, rios, wios, dbytes and dios.  The v1 blkio counters
+	// are reported with the same names.
+	Stats map[string]int64
+}
+
+// Pressure is the pressure stall information of a resource, as found in the
+// cpu.pressure, memory.pressure and io.pressure files.
+// Pressure is only available on linux.
+type Pressure struct {
+	Some PressureStat // Time at least one task was stalled on the resource
+	Full PressureStat // Time all non-idle tasks were stalled on the resource
+}
+
+// A PressureStat is a single line of a pressure file.
+// PressureStat is only available on linux.
+type PressureStat struct {
+	Avg10  float64 // Percentage of the last 10 seconds spent stalled
+	Avg60  float64 // Percentage of the last 60 seconds spent stalled
+	Avg300 float64 // Percentage of the last 300 seconds spent stalled
+	Total  time.Duration
+}
+
+// v1Unlimited is the smallest value treated as unlimited in a v1 limit file.
+// The kernel reports no limit as the largest page aligned int64.
+const v1Unlimited = 1 << 62
+
+// Usage returns the resource usage of the cgroup p is a member of.
+func (c *Cgroupfs) Usage(p *Process) (*CgroupUsage, error) {
+	cgroups, err := p.Cgroups()
+	if err != nil {
+		return nil, err
+	}
+	var v2 *Cgroup
+	for i := range cgroups {
+		if cgroups[i].IsV2() {
+			v2 = &cgroups[i]
+		}
+	}
+	if c.exists(path.Join(c.root, "cgroup.controllers")) {
+		if v2 == nil {
+			return nil, fmt.Errorf("process %d is not in the unified cgroup hierarchy", p.ID)
+		}
+		return c.usageV2(v2.Path)
+	}
+	return c.usageV1(cgroups, v2)
+}
+
+// CgroupUsage returns the resource usage of the cgroup p is a member of, as
+// found in /sys/fs/cgroup.  See Cgroupfs for details.
+// CgroupUsage is only available on linux.
+func (p *Process) CgroupUsage() (*CgroupUsage, error) {
+	return defaultCgroupfs.Usage(p)
+}
+
+func (c *Cgroupfs) usageV2(cgpath string) (*CgroupUsage, error) {
+	dir := path.Join(c.root, cgpath)
+	if !c.exists(dir) {
+		return nil, fmt.Errorf("cgroup %s not found", cgpath)
+	}
+	u := &CgroupUsage{
+		Version: 2,
+		Path:    cgpath,
+	}
+	u.MemoryCurrent, _ = c.readInt(dir + "/memory.current")
+	u.MemoryMax, _ = c.readInt(dir + "/memory.max")
+	u.MemoryStat, _ = c.readKeyed(dir + "/memory.stat")
+	if stat, err := c.readKeyed(dir + "/cpu.stat"); err == nil {
+		u.CPUStat = stat
+		u.CPUTotal = time.Duration(stat["usage_usec"]) * time.Microsecond
+		u.CPUUser = time.Duration(stat["user_usec"]) * time.Microsecond
+		u.CPUSystem = time.Duration(stat["system_usec"]) * time.Microsecond
+	}
+	u.PidsCurrent, _ = c.readInt(dir + "/pids.current")
+	u.PidsMax, _ = c.readInt(dir + "/pids.max")
+	if data, err := c.readFile(dir + "/io.stat"); err == nil {
+		u.IO, _ = parseIOStat(data)
+	}
+	u.Pressure = c.readPressure(dir)
+	return u, nil
+}
+
+func (c *Cgroupfs) usageV1(cgroups []Cgroup, v2 *Cgroup) (*CgroupUsage, error) {
+	u := &CgroupUsage{Version: 1}
+	found := false
+	// dir returns the directory of the cgroup with controller, or "" if
+	// there is none.  The path of the first cgroup found, normally the
+	// memory cgroup, is reported in u.
+	dir := func(controller string) string {
+		for _, cg := range cgroups {
+			if !cg.Has(controller) {
+				continue
+			}
+			for _, mount := range []string{strings.Join(cg.Controllers, ","), controller} {
+				if d := path.Join(c.root, mount, cg.Path); c.exists(d) {
+					if u.Path == "" {
+						u.Path = cg.Path
+					}
+					found = true
+					return d
+				}
+			}
+		}
+		return ""
+	}
+	if d := dir("memory"); d != "" {
+		u.MemoryCurrent, _ = c.readInt(d + "/memory.usage_in_bytes")
+		if max, err := c.readInt(d + "/memory.limit_in_bytes"); err == nil {
+			if max >= v1Unlimited {
+				max = -1
+			}
+			u.MemoryMax = max
+		}
+		u.MemoryStat, _ = c.readKeyed(d + "/memory.stat")
+	}
+	if d := dir("cpu"); d != "" {
+		u.CPUStat, _ = c.readKeyed(d + "/cpu.stat")
+	}
+	if d := dir("cpuacct"); d != "" {
+		if total, err := c.readInt(d + "/cpuacct.usage"); err == nil {
+			u.CPUTotal = time.Duration(total)
+		}
+		if stat, err := c.readKeyed(d + "/cpuacct.stat"); err == nil {
+			u.CPUUser = ticksToDuration(uint64(stat["user"]))
+			u.CPUSystem = ticksToDuration(uint64(stat["system"]))
+		}
+	}
+	if d := dir("pids"); d != "" {
+		u.PidsCurrent, _ = c.readInt(d + "/pids.current")
+		u.PidsMax, _ = c.readInt(d + "/pids.max")
+	}
+	if d := dir("blkio"); d != "" {
+		nbytes, _ := c.readFile(d + "/blkio.throttle.io_service_bytes")
+		nios, _ := c.readFile(d + "/blkio.throttle.io_serviced")
+		u.IO = parseBlkio(nbytes, nios)
+	}
+	if !found {
+		return nil, errors.New("cgroup not found")
+	}
+	if v2 != nil {
+		if d := path.Join(c.root, "unified", v2.Path); c.exists(d) {
+			u.Pressure = c.readPressure(d)
+		}
+	}
+	return u, nil
+}
+
+// readPressure returns the pressure files found in dir.
+func (c *Cgroupfs) readPressure(dir string) map[string]Pressure {
+	var pressure map[string]Pressure
+	for _, resource := range []string{"cpu", "memory", "io"} {
+		data, err := c.readFile(dir + "/" + resource + ".pressure")
+		if err != nil {
+			continue
+		}
+		p, err := parsePressure(data)
+		if err != nil {
+			continue
+		}
+		if pressure == nil {
+			pressure = map[string]Pressure{}
+		}
+		pressure[resource] = p
+	}
+	return pressure
+}
+
+// parsePressure parses the contents of a pressure file, e.g.:
+//
+//	some avg10=0.00 avg60=0.00 avg300=0.00 total=0
+//	full avg10=0.00 avg60=0.00 avg300=0.00 total=0
+func parsePressure(data []byte) (Pressure, error) {
+	var p Pressure
+	for _, line := range bytes.Split(data, []byte{'\n'}) {
+		f := strings.Fields(string(line))
+		if len(f) == 0 {
+			continue
+		}
+		var ps *PressureStat
+		switch f[0] {
+		case "some":
+			ps = &p.Some
+		case "full":
+			ps = &p.Full
+		default:
+			return p, fmt.Errorf("invalid pressure line %q", line)
+		}
+		for _, kv := range f[1:] {
+			x := strings.IndexByte(kv, '=')
+			if x < 0 {
+				return p, fmt.Errorf("invalid pressure line %q", line)
+			}
+			var err error
+			switch kv[:x] {
+			case "avg10":
+				ps.Avg10, err = strconv.ParseFloat(kv[x+1:], 64)
+			case "avg60":
+				ps.Avg60, err = strconv.ParseFloat(kv[x+1:], 64)
+			case "avg300":
+				ps.Avg300, err = strconv.ParseFloat(kv[x+1:], 64)
+			case "total":
+				var total int64
+				total, err = strconv.ParseInt(kv[x+1:], 10, 64)
+				ps.Total = time.Duration(total) * time.Microsecond
+			}
+			if err != nil {
+				return p, fmt.Errorf("invalid pressure line %q", line)
+			}
+		}
+	}
+	return p, nil
+}
+
+// parseIOStat parses the contents of a cgroup v2 io.stat file, e.g.:
+//
+//	8:0 rbytes=90112 wbytes=0 rios=8 wios=0 dbytes=0 dios=0
+func parseIOStat(data []byte) ([]CgroupIO, error) {
+	var ios []CgroupIO
+	for _, line := range bytes.Split(data, []byte{'\n'}) {
+		f := strings.Fields(string(line))
+		if len(f) == 0 {
+			continue
+		}
+		dev, err := parseDevice(f[0])
+		if err != nil {
+			return nil, err
+		}
+		for _, kv := range f[1:] {
+			x := strings.IndexByte(kv, '=')
+			if x < 0 {
+				return nil, fmt.Errorf("invalid io.stat line %q", line)
+			}
+			v, err := strconv.ParseInt(kv[x+1:], 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid io.stat line %q", line)
+			}
+			dev.Stats[kv[:x]] = v
+		}
+		ios = append(ios, dev)
+	}
+	return ios, nil
+}
+
+// parseBlkio converts the contents of the v1 blkio.throttle.io_service_bytes
+// and blkio.throttle.io_serviced files into the form of io.stat.
+func parseBlkio(bytesData, iosData []byte) []CgroupIO {
+	var ios []CgroupIO
+	index := map[string]int{}
+	for _, f := range []struct {
+		data   []byte
+		suffix string
+	}{
+		{bytesData, "bytes"},
+		{iosData, "ios"},
+	} {
+		for _, line := range strings.Split(string(f.data), "\n") {
+			// E.g., "8:0 Read 4096"
+			fields := strings.Fields(line)
+			if len(fields) != 3 {
+				continue // blank or the final Total line
+			}
+			var prefix string
+			switch fields[1] {
+			case "Read":
+				prefix = "r"
+			case "Write":
+				prefix = "w"
+			case "Discard":
+				prefix = "d"
+			default:
+				continue
+			}
+			v, err := strconv.ParseInt(fields[2], 10, 64)
+			if err != nil {
+				continue
+			}
+			x, ok := index[fields[0]]
+			if !ok {
+				dev, err := parseDevice(fields[0])
+				if err != nil {
+					continue
+				}
+				x = len(ios)
+				index[fields[0]] = x
+				ios = append(ios, dev)
+			}
+			ios[x].Stats[prefix+f.suffix] = v
+		}
+	}
+	return ios
+}
+
+// parseDevice returns a CgroupIO for the device s, in the form MAJOR:MINOR.
+func parseDevice(s string) (CgroupIO, error) {
+	x := strings.IndexByte(s, ':')
+	if x < 0 {
+		return CgroupIO{}, fmt.Errorf("invalid device %q", s)
+	}
+	major, err := strconv.Atoi(s[:x])
+	if err != nil {
+		return CgroupIO{}, fmt.Errorf("invalid device %q", s)
+	}
+	minor, err := strconv.Atoi(s[x+1:])
+	if err != nil {
+		return CgroupIO{}, fmt.Errorf("invalid device %q", s)
+	}
+	return CgroupIO{Major: major, Minor: minor, Stats: map[string]int64{}}, nil
+}
+
+func (c *Cgroupfs) readFile(name string) ([]byte, error) {
+	if c.fsys != nil {
+		return fs.ReadFile(c.fsys, name)
+	}
+	return ioutil.ReadFile(name)
+}
+
+func (c *Cgroupfs) exists(name string) bool {
+	var err error
+	if c.fsys != nil {
+		_, err = fs.Stat(c.fsys, name)
+	} else {
+		_, err = os.Stat(name)
+	}
+	return err == nil
+}
+
+// readInt returns the single integer in the file name.  The value "max" is
+// returned as -1.
+func (c *Cgroupfs) readInt(name string) (int64, error) {
+	data, err := c.readFile(name)
+	if err != nil {
+		return 0, err
+	}
+	s := string(bytes.TrimSpace(data))
+	if s == "max" {
+		return -1, nil
+	}
+	return strconv.ParseInt(s, 10, 64)
+}
+
+// readKeyed returns the contents of the file name, which contains lines of
+// the form "KEY VALUE".
+func (c *Cgroupfs) readKeyed(name string) (map[string]int64, error) {
+	data, err := c.readFile(name)
+	if err != nil {
+		return nil, err
+	}
+	m := map[string]int64{}
+	for _, line := range bytes.Split(data, []byte{'\n'}) {
+		f := bytes.Fields(line)
+		if len(f) != 2 {
+			continue
+		}
+		v, err := strconv.ParseInt(string(f[1]), 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%s: invalid line %q", name, line)
+		}
+		m[string(f[0])] = v
+	}
+	return m, nil
+}
@@ -0,0 +1,208 @@
+//go:build linux
+
+package ps
+
+import (
+	"reflect"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+const testPSI = "some avg10=1.50 avg60=0.25 avg300=0.00 total=123456\nfull avg10=0.00 avg60=0.00 avg300=0.00 total=42\n"
+
+// testCgroupV2FS returns a unified cgroup file system containing the cgroup
+// of sshd.service.
+func testCgroupV2FS() fstest.MapFS {
+	const dir = "system.slice/sshd.service/"
+	return fstest.MapFS{
+		"cgroup.controllers":    {Data: []byte("cpuset cpu io memory pids\n")},
+		dir + "cgroup.procs":    {Data: []byte("1\n")},
+		dir + "memory.current":  {Data: []byte("5242880\n")},
+		dir + "memory.max":      {Data: []byte("max\n")},
+		dir + "memory.stat":     {Data: []byte("anon 1048576\nfile 4194304\n")},
+		dir + "cpu.stat":        {Data: []byte("usage_usec 3000\nuser_usec 2000\nsystem_usec 1000\nnr_periods 0\nnr_throttled 0\nthrottled_usec 0\n")},
+		dir + "pids.current":    {Data: []byte("3\n")},
+		dir + "pids.max":        {Data: []byte("100\n")},
+		dir + "io.stat":         {Data: []byte("8:0 rbytes=90112 wbytes=4096 rios=8 wios=1 dbytes=0 dios=0\n253:1 rbytes=1 wbytes=2 rios=3 wios=4 dbytes=5 dios=6\n")},
+		dir + "cpu.pressure":    {Data: []byte(testPSI)},
+		dir + "memory.pressure": {Data: []byte(testPSI)},
+		dir + "io.pressure":     {Data: []byte(testPSI)},
+	}
+}
+
+// testCgroupV1FS returns a hybrid cgroup file system containing the cgroup
+// of sshd.service.
+func testCgroupV1FS() fstest.MapFS {
+	const dir = "/system.slice/sshd.service/"
+	return fstest.MapFS{
+		"memory" + dir + "memory.usage_in_bytes":          {Data: []byte("5242880\n")},
+		"memory" + dir + "memory.limit_in_bytes":          {Data: []byte("9223372036854771712\n")},
+		"memory" + dir + "memory.stat":                    {Data: []byte("cache 4194304\nrss 1048576\n")},
+		"cpu,cpuacct" + dir + "cpu.stat":                  {Data: []byte("nr_periods 10\nnr_throttled 2\nthrottled_time 5000\n")},
+		"cpu,cpuacct" + dir + "cpuacct.usage":             {Data: []byte("3000000\n")},
+		"cpu,cpuacct" + dir + "cpuacct.stat":              {Data: []byte("user 20\nsystem 10\n")},
+		"pids" + dir + "pids.current":                     {Data: []byte("3\n")},
+		"pids" + dir + "pids.max":                         {Data: []byte("max\n")},
+		"blkio" + dir + "blkio.throttle.io_service_bytes": {Data: []byte("8:0 Read 90112\n8:0 Write 4096\n8:0 Sync 0\n8:0 Async 94208\n8:0 Discard 0\n8:0 Total 94208\nTotal 94208\n")},
+		"blkio" + dir + "blkio.throttle.io_serviced":      {Data: []byte("8:0 Read 8\n8:0 Write 1\n8:0 Sync 0\n8:0 Async 9\n8:0 Discard 0\n8:0 Total 9\nTotal 9\n")},
+		"unified" + dir + "cgroup.procs":                  {Data: []byte("1\n")},
+		"unified" + dir + "memory.pressure":               {Data: []byte(testPSI)},
+		"systemd" + dir + "cgroup.procs":                  {Data: []byte("1\n")},
+		"unified/cgroup.controllers":                      {},
+	}
+}
+
+func TestCgroupUsageV2(t *testing.T) {
+	fsys := testProcFS()
+	fsys.fs["1/cgroup"] = &fstest.MapFile{Data: []byte("0::/system.slice/sshd.service\n")}
+	fsys.fs["3/cgroup"] = &fstest.MapFile{Data: []byte("0::/missing\n")}
+	fsys.fs["100/cgroup"] = &fstest.MapFile{Data: []byte("1:name=systemd:/\n")}
+	src := NewSourceFS(fsys)
+	cfs := NewCgroupfsFS(testCgroupV2FS())
+
+	p, err := src.ProcessByPid(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	u, err := cfs.Usage(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	psi := PressureStat{Avg10: 1.5, Avg60: 0.25, Total: 123456 * time.Microsecond}
+	want := &CgroupUsage{
+		Version:       2,
+		Path:          "/system.slice/sshd.service",
+		MemoryCurrent: 5 << 20,
+		MemoryMax:     -1,
+		MemoryStat:    map[string]int64{"anon": 1 << 20, "file": 4 << 20},
+		CPUTotal:      3 * time.Millisecond,
+		CPUUser:       2 * time.Millisecond,
+		CPUSystem:     1 * time.Millisecond,
+		CPUStat: map[string]int64{
+			"usage_usec":     3000,
+			"user_usec":      2000,
+			"system_usec":    1000,
+			"nr_periods":     0,
+			"nr_throttled":   0,
+			"throttled_usec": 0,
+		},
+		PidsCurrent: 3,
+		PidsMax:     100,
+		IO: []CgroupIO{
+			{8, 0, map[string]int64{"rbytes": 90112, "wbytes": 4096, "rios": 8, "wios": 1, "dbytes": 0, "dios": 0}},
+			{253, 1, map[string]int64{"rbytes": 1, "wbytes": 2, "rios": 3, "wios": 4, "dbytes": 5, "dios": 6}},
+		},
+		Pressure: map[string]Pressure{
+			"cpu":    {Some: psi, Full: PressureStat{Total: 42 * time.Microsecond}},
+			"memory": {Some: psi, Full: PressureStat{Total: 42 * time.Microsecond}},
+			"io":     {Some: psi, Full: PressureStat{Total: 42 * time.Microsecond}},
+		},
+	}
+	if !reflect.DeepEqual(u, want) {
+		t.Errorf("Got usage\n%+v\nwant\n%+v", u, want)
+	}
+
+	for _, pid := range []int{3, 100} {
+		p, err := src.ProcessByPid(pid)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if u, err := cfs.Usage(p); err == nil {
+			t.Errorf("%d: got usage %+v, want an error", pid, u)
+		}
+	}
+}
+
+func TestCgroupUsageV1(t *testing.T) {
+	fsys := testProcFS()
+	fsys.fs["1/cgroup"] = &fstest.MapFile{Data: []byte(
+		"5:pids:/system.slice/sshd.service\n" +
+			"4:blkio:/system.slice/sshd.service\n" +
+			"3:memory:/system.slice/sshd.service\n" +
+			"2:cpu,cpuacct:/system.slice/sshd.service\n" +
+			"1:name=systemd:/system.slice/sshd.service\n" +
+			"0::/system.slice/sshd.service\n")}
+	fsys.fs["3/cgroup"] = &fstest.MapFile{Data: []byte("3:memory:/missing\n")}
+	src := NewSourceFS(fsys)
+	cfs := NewCgroupfsFS(testCgroupV1FS())
+
+	p, err := src.ProcessByPid(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	u, err := cfs.Usage(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tick := time.Second / time.Duration(clockTicks())
+	want := &CgroupUsage{
+		Version:       1,
+		Path:          "/system.slice/sshd.service",
+		MemoryCurrent: 5 << 20,
+		MemoryMax:     -1,
+		MemoryStat:    map[string]int64{"cache": 4 << 20, "rss": 1 << 20},
+		CPUTotal:      3 * time.Millisecond,
+		CPUUser:       20 * tick,
+		CPUSystem:     10 * tick,
+		CPUStat:       map[string]int64{"nr_periods": 10, "nr_throttled": 2, "throttled_time": 5000},
+		PidsCurrent:   3,
+		PidsMax:       -1,
+		IO: []CgroupIO{
+			{8, 0, map[string]int64{"rbytes": 90112, "wbytes": 4096, "dbytes": 0, "rios": 8, "wios": 1, "dios": 0}},
+		},
+		Pressure: map[string]Pressure{
+			"memory": {
+				Some: PressureStat{Avg10: 1.5, Avg60: 0.25, Total: 123456 * time.Microsecond},
+				Full: PressureStat{Total: 42 * time.Microsecond},
+			},
+		},
+	}
+	if !reflect.DeepEqual(u, want) {
+		t.Errorf("Got usage\n%+v\nwant\n%+v", u, want)
+	}
+
+	p, err = src.ProcessByPid(3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if u, err := cfs.Usage(p); err == nil {
+		t.Errorf("Got usage %+v, want an error", u)
+	}
+}
+
+func TestParsePressure(t *testing.T) {
+	// Older kernels do not report full for cpu.
+	p, err := parsePressure([]byte("some avg10=0.10 avg60=0.20 avg300=0.30 total=1000\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := Pressure{Some: PressureStat{0.1, 0.2, 0.3, time.Millisecond}}
+	if p != want {
+		t.Errorf("Got %+v, want %+v", p, want)
+	}
+	for _, bad := range []string{
+		"partial avg10=0.00\n",
+		"some avg10\n",
+		"some avg10=x\n",
+	} {
+		if _, err := parsePressure([]byte(bad)); err == nil {
+			t.Errorf("%q: did not get an error", bad)
+		}
+	}
+}
+
+func TestCgroupUsageLive(t *testing.T) {
+	p := &Process{ID: mypid}
+	u, err := p.CgroupUsage()
+	if err != nil {
+		t.Skipf("cgroup usage not available: %v", err)
+	}
+	if u.Version != 1 && u.Version != 2 {
+		t.Errorf("Got version %d", u.Version)
+	}
+	if u.MemoryCurrent <= 0 {
+		t.Errorf("Got memory usage %d", u.MemoryCurrent)
+	}
+	t.Logf("%s: memory %d/%d, cpu %v, pids %d/%d", u.Path, u.MemoryCurrent, u.MemoryMax, u.CPUTotal, u.PidsCurrent, u.PidsMax)
+}
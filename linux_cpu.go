@@ -0,0 +1,202 @@
+//go:build linux
+
+package ps
+
+import (
+	"bytes"
+	"io/ioutil"
+	"path"
+	"runtime"
+	"sort"
+	"sync"
+	"time"
+	"unsafe"
+)
+
+// A CPUSnapshot records the CPU time used by each process at a point in time.
+// The CPU usage between two snapshots is reported by Since.
+// CPUSnapshot is only available on linux.
+type CPUSnapshot struct {
+	Time   time.Time // When the snapshot was taken
+	NumCPU int       // The number of CPUs on the system
+	procs  map[int]cpuTimes
+}
+
+type cpuTimes struct {
+	p         *Process
+	starttime uint64
+	utime     uint64
+	stime     uint64
+}
+
+// A CPUUsage is the CPU utilization of a process between two snapshots.
+// Percentages are relative to a single CPU (as reported by ps and top), in
+// which case a process using 2 CPUs is at 200%, or normalized across all CPUs,
+// in which case a process using every CPU is at 100%.
+type CPUUsage struct {
+	Process *Process
+	User    float64 // percent of one CPU spent in user mode
+	System  float64 // percent of one CPU spent in system mode
+	Total   float64 // User + System
+
+	NormUser   float64 // User divided by the number of CPUs
+	NormSystem float64 // System divided by the number of CPUs
+	NormTotal  float64 // Total divided by the number of CPUs
+
+	// New is set if the process was not in the earlier snapshot, either
+	// because it was started or because its PID was reused.  The usage of a
+	// new process is all the CPU time it used divided by the interval.
+	New bool
+}
+
+// SnapshotCPU returns a snapshot of the CPU time used by each process in s.
+// Processes that cannot be read are not included.
+func (s *Source) SnapshotCPU() (*CPUSnapshot, error) {
+	procs, err := s.Processes(false)
+	if err != nil {
+		return nil, err
+	}
+	snap := &CPUSnapshot{
+		Time:   time.Now(),
+		NumCPU: s.numCPU(),
+		procs:  make(map[int]cpuTimes, len(procs)),
+	}
+	for _, p := range procs {
+		st, err := p.Stat()
+		if err != nil {
+			continue
+		}
+		snap.procs[p.ID] = cpuTimes{
+			p:         p,
+			starttime: st.Starttime,
+			utime:     st.Utime,
+			stime:     st.Stime,
+		}
+	}
+	return snap, nil
+}
+
+// SnapshotCPU returns a snapshot of the CPU time used by each process on the
+// system.
+// SnapshotCPU is only available on linux.
+func SnapshotCPU() (*CPUSnapshot, error) {
+	return defaultSource.SnapshotCPU()
+}
+
+// Since returns the CPU usage of each process in cur between the earlier
+// snapshot prev and cur, sorted by process ID.  Processes that exited between
+// the snapshots are not reported.  A process whose PID was reused is reported
+// as a new process.
+func (cur *CPUSnapshot) Since(prev *CPUSnapshot) []CPUUsage {
+	interval := cur.Time.Sub(prev.Time).Seconds()
+	if interval <= 0 {
+		return nil
+	}
+	ncpu := cur.NumCPU
+	if ncpu < 1 {
+		ncpu = 1
+	}
+	hz := float64(clockTicks())
+	usage := make([]CPUUsage, 0, len(cur.procs))
+	for pid, c := range cur.procs {
+		u := CPUUsage{Process: c.p}
+		utime, stime := c.utime, c.stime
+		if p, ok := prev.procs[pid]; ok && p.starttime == c.starttime {
+			utime -= p.utime
+			stime -= p.stime
+		} else {
+			u.New = true
+		}
+		u.User = float64(utime) * 100 / hz / interval
+		u.System = float64(stime) * 100 / hz / interval
+		u.Total = u.User + u.System
+		u.NormUser = u.User / float64(ncpu)
+		u.NormSystem = u.System / float64(ncpu)
+		u.NormTotal = u.Total / float64(ncpu)
+		usage = append(usage, u)
+	}
+	sort.Slice(usage, func(i, j int) bool {
+		return usage[i].Process.ID < usage[j].Process.ID
+	})
+	return usage
+}
+
+// SampleCPU takes two snapshots of s, interval apart, and returns the CPU
+// usage of each process between them.
+func (s *Source) SampleCPU(interval time.Duration) ([]CPUUsage, error) {
+	prev, err := s.SnapshotCPU()
+	if err != nil {
+		return nil, err
+	}
+	time.Sleep(interval)
+	cur, err := s.SnapshotCPU()
+	if err != nil {
+		return nil, err
+	}
+	return cur.Since(prev), nil
+}
+
+// SampleCPU returns the CPU usage of each process on the system over the
+// next interval.
+// SampleCPU is only available on linux.
+func SampleCPU(interval time.Duration) ([]CPUUsage, error) {
+	return defaultSource.SampleCPU(interval)
+}
+
+// numCPU returns the number of CPUs listed in the stat file of s.  If the
+// file cannot be read then the number of CPUs usable by this process is
+// returned.
+func (s *Source) numCPU() int {
+	data, err := s.readFile(path.Join(s.root, "stat"))
+	if err != nil {
+		return runtime.NumCPU()
+	}
+	n := 0
+	for _, line := range bytes.Split(data, []byte{'\n'}) {
+		if len(line) > 3 && bytes.HasPrefix(line, []byte("cpu")) && line[3] >= '0' && line[3] <= '9' {
+			n++
+		}
+	}
+	if n == 0 {
+		return runtime.NumCPU()
+	}
+	return n
+}
+
+const (
+	_AT_NULL   = 0
+	_AT_CLKTCK = 17
+)
+
+var (
+	clkTckOnce sync.Once
+	clkTck     int64
+)
+
+// clockTicks returns the number of clock ticks per second (USER_HZ) used by
+// the kernel when reporting times in /proc.  It is read from the auxiliary
+// vector of the current process and defaults to 100.
+func clockTicks() int64 {
+	clkTckOnce.Do(func() {
+		clkTck = 100
+		data, err := ioutil.ReadFile("/proc/self/auxv")
+		if err != nil {
+			return
+		}
+		const size = int(unsafe.Sizeof(uintptr(0)))
+		for i := 0; i+2*size <= len(data); i += 2 * size {
+			key := *(*uintptr)(unsafe.Pointer(&data[i]))
+			value := *(*uintptr)(unsafe.Pointer(&data[i+size]))
+			switch key {
+			case _AT_NULL:
+				return
+			case _AT_CLKTCK:
+				if value > 0 {
+					clkTck = int64(value)
+				}
+				return
+			}
+		}
+	})
+	return clkTck
+}
@@ -0,0 +1,95 @@
+//go:build linux
+
+package ps
+
+import (
+	"math"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+func TestCPUSnapshot(t *testing.T) {
+	fs1 := testProcFS()
+	first, err := NewSourceFS(fs1).SnapshotCPU()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first.NumCPU != 2 {
+		t.Errorf("Got %d CPUs, want 2", first.NumCPU)
+	}
+
+	hz := uint64(clockTicks())
+	fs2 := testProcFS()
+	// init used 1 CPU in user mode and half a CPU in system mode.
+	fs2.fs["1/stat"].Data = testStat(1, "systemd", 'S', 0, 100+2*hz, 50+hz, 1)
+	// 100 exited and its PID was reused.
+	fs2.fs["100/stat"].Data = testStat(100, "sleep", 'S', 1, 0, hz/2, 350)
+	// 200 exited.
+	delete(fs2.fs, "200/stat")
+	// 300 was started.
+	fs2.fs["300/stat"] = &fstest.MapFile{Data: testStat(300, "make", 'R', 1, hz, 0, 360)}
+	second, err := NewSourceFS(fs2).SnapshotCPU()
+	if err != nil {
+		t.Fatal(err)
+	}
+	second.Time = first.Time.Add(2 * time.Second)
+
+	type usage struct {
+		pid                 int
+		user, system, total float64
+		normTotal           float64
+		isNew               bool
+	}
+	var got []usage
+	for _, u := range second.Since(first) {
+		got = append(got, usage{u.Process.ID, u.User, u.System, u.Total, u.NormTotal, u.New})
+	}
+	want := []usage{
+		{1, 100, 50, 150, 75, false},
+		{2, 0, 0, 0, 0, false},
+		{3, 0, 0, 0, 0, false},
+		{100, 0, 25, 25, 12.5, true},
+		{300, 50, 0, 50, 25, true},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("Got usage %v, want %v", got, want)
+	}
+	near := func(a, b float64) bool { return math.Abs(a-b) < 1e-9 }
+	for i, g := range got {
+		w := want[i]
+		if g.pid != w.pid || g.isNew != w.isNew || !near(g.user, w.user) || !near(g.system, w.system) || !near(g.total, w.total) || !near(g.normTotal, w.normTotal) {
+			t.Errorf("Got usage %v, want %v", g, w)
+		}
+	}
+	if u := first.Since(first); u != nil {
+		t.Errorf("Got usage over empty interval: %v", u)
+	}
+}
+
+func TestSampleCPU(t *testing.T) {
+	done := make(chan struct{})
+	go func() {
+		for {
+			select {
+			case <-done:
+				return
+			default:
+			}
+		}
+	}()
+	usage, err := SampleCPU(200 * time.Millisecond)
+	close(done)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, u := range usage {
+		if u.Process.ID == mypid {
+			if u.Total <= 0 {
+				t.Errorf("Got %.2f%% CPU, want more than 0", u.Total)
+			}
+			return
+		}
+	}
+	t.Errorf("My PID was not found")
+}
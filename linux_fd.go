@@ -0,0 +1,197 @@
+//go:build linux
+
+package ps
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+// An FDKind is the kind of file an open file descriptor refers to.
+// FDKind is only available on linux.
+type FDKind int
+
+const (
+	FDUnknown   = FDKind(iota) // Could not be determined
+	FDFile                     // A regular file
+	FDDir                      // A directory
+	FDDevice                   // A character or block device
+	FDSocket                   // A socket
+	FDPipe                     // A pipe or FIFO
+	FDEventfd                  // An eventfd
+	FDEpoll                    // An epoll instance
+	FDTimerfd                  // A timerfd
+	FDInotify                  // An inotify instance
+	FDSignalfd                 // A signalfd
+	FDMemfd                    // A memfd
+	FDAnonInode                // Some other anonymous inode
+)
+
+var fdKindNames = []string{
+	FDUnknown:   "unknown",
+	FDFile:      "file",
+	FDDir:       "dir",
+	FDDevice:    "device",
+	FDSocket:    "socket",
+	FDPipe:      "pipe",
+	FDEventfd:   "eventfd",
+	FDEpoll:     "epoll",
+	FDTimerfd:   "timerfd",
+	FDInotify:   "inotify",
+	FDSignalfd:  "signalfd",
+	FDMemfd:     "memfd",
+	FDAnonInode: "anon_inode",
+}
+
+func (k FDKind) String() string {
+	if k >= 0 && int(k) < len(fdKindNames) {
+		return fdKindNames[k]
+	}
+	return fmt.Sprintf("FDKind(%d)", int(k))
+}
+
+// An FD is an open file descriptor of a process as found in /proc/PID/fd and
+// /proc/PID/fdinfo.
+// FD is only available on linux.
+type FD struct {
+	FD     int    // The file descriptor number
+	Target string // The target of the link in /proc/PID/fd, e.g., "pipe:[1234]"
+	Kind   FDKind // The kind of file
+	Flags  int    // The flags the file was opened with, e.g., syscall.O_RDWR
+	Pos    int64  // The current file offset
+	Inode  uint64 // The inode of the file, if known
+}
+
+// Path returns the pathname of the file fd refers to, or "" if fd does not
+// refer to a file in the file system.
+func (fd *FD) Path() string {
+	if strings.HasPrefix(fd.Target, "/") && fd.Kind != FDMemfd {
+		return strings.TrimSuffix(fd.Target, " (deleted)")
+	}
+	return ""
+}
+
+// FDs returns the open file descriptors of p, sorted by number.  The flags and
+// position are read from /proc/PID/fdinfo when it is available.  Non-root
+// users will receive an error when requesting information about a process
+// with a different UID.
+// FDs is only available on linux.
+func (p *Process) FDs() ([]FD, error) {
+	src := p.source()
+	dir := p.dirname()
+	names, err := src.readDirNames(dir + "/fd")
+	if err != nil {
+		return nil, fixError(err)
+	}
+	fds := make([]FD, 0, len(names))
+	for _, name := range names {
+		n, err := strconv.Atoi(name)
+		if err != nil {
+			continue
+		}
+		target, err := src.readlink(dir + "/fd/" + name)
+		if err != nil {
+			continue // The descriptor was closed.
+		}
+		fd := FD{
+			FD:     n,
+			Target: target,
+		}
+		if data, err := src.readFile(dir + "/fdinfo/" + name); err == nil {
+			fd.parseInfo(data)
+		}
+		fd.classify()
+		if (fd.Kind == FDFile || fd.Kind == FDDevice) && src.fsys == nil {
+			// The pathname does not say what kind of file it is:
+			// directories are not always opened with O_DIRECTORY and
+			// FIFOs, sockets and devices can be anywhere.
+			if st, err := src.stat(dir + "/fd/" + name); err == nil {
+				fd.statKind(st.Mode)
+			}
+		}
+		fds = append(fds, fd)
+	}
+	sort.Slice(fds, func(i, j int) bool { return fds[i].FD < fds[j].FD })
+	return fds, nil
+}
+
+// parseInfo fills in fd from the contents of its fdinfo file.
+func (fd *FD) parseInfo(data []byte) {
+	for _, line := range bytes.Split(data, []byte{'\n'}) {
+		f := bytes.Fields(line)
+		if len(f) < 2 {
+			continue
+		}
+		switch string(f[0]) {
+		case "pos:":
+			fd.Pos, _ = strconv.ParseInt(string(f[1]), 10, 64)
+		case "flags:":
+			flags, _ := strconv.ParseInt(string(f[1]), 8, 64)
+			fd.Flags = int(flags)
+		case "ino:":
+			fd.Inode, _ = strconv.ParseUint(string(f[1]), 10, 64)
+		}
+	}
+}
+
+// statKind sets the kind of fd, which refers to a pathname, from mode, the
+// st_mode of the file it refers to.
+func (fd *FD) statKind(mode uint32) {
+	switch mode & syscall.S_IFMT {
+	case syscall.S_IFREG:
+		fd.Kind = FDFile
+	case syscall.S_IFDIR:
+		fd.Kind = FDDir
+	case syscall.S_IFIFO:
+		fd.Kind = FDPipe
+	case syscall.S_IFSOCK:
+		fd.Kind = FDSocket
+	case syscall.S_IFCHR, syscall.S_IFBLK:
+		fd.Kind = FDDevice
+	}
+}
+
+// classify sets the kind of fd based on its target and flags.
+func (fd *FD) classify() {
+	t := fd.Target
+	inode := func(prefix string) {
+		if fd.Inode == 0 && strings.HasSuffix(t, "]") {
+			fd.Inode, _ = strconv.ParseUint(t[len(prefix):len(t)-1], 10, 64)
+		}
+	}
+	switch {
+	case strings.HasPrefix(t, "socket:["):
+		fd.Kind = FDSocket
+		inode("socket:[")
+	case strings.HasPrefix(t, "pipe:["):
+		fd.Kind = FDPipe
+		inode("pipe:[")
+	case strings.HasPrefix(t, "anon_inode:"):
+		switch strings.Trim(t[len("anon_inode:"):], "[]") {
+		case "eventfd":
+			fd.Kind = FDEventfd
+		case "eventpoll":
+			fd.Kind = FDEpoll
+		case "timerfd":
+			fd.Kind = FDTimerfd
+		case "inotify":
+			fd.Kind = FDInotify
+		case "signalfd":
+			fd.Kind = FDSignalfd
+		default:
+			fd.Kind = FDAnonInode
+		}
+	case strings.HasPrefix(t, "/memfd:"):
+		fd.Kind = FDMemfd
+	case fd.Flags&syscall.O_DIRECTORY != 0:
+		fd.Kind = FDDir
+	case strings.HasPrefix(t, "/dev/") && !strings.HasPrefix(t, "/dev/shm/") && !strings.HasPrefix(t, "/dev/mqueue/"):
+		fd.Kind = FDDevice
+	case strings.HasPrefix(t, "/"):
+		fd.Kind = FDFile
+	}
+}
@@ -0,0 +1,135 @@
+//go:build linux
+
+package ps
+
+import (
+	"os"
+	"syscall"
+	"testing"
+	"testing/fstest"
+)
+
+func TestFDs(t *testing.T) {
+	fsys := testProcFS()
+	for name, target := range map[string]string{
+		"1/fd/1":  "/var/log/syslog",
+		"1/fd/2":  "/dev/pts/0",
+		"1/fd/4":  "pipe:[555]",
+		"1/fd/5":  "anon_inode:[eventfd]",
+		"1/fd/6":  "anon_inode:[eventpoll]",
+		"1/fd/7":  "anon_inode:[timerfd]",
+		"1/fd/8":  "anon_inode:inotify",
+		"1/fd/9":  "/memfd:buffer (deleted)",
+		"1/fd/10": "/etc",
+		"1/fd/11": "anon_inode:bpf-map",
+		"1/fd/12": "/tmp/old (deleted)",
+		"1/fd/13": "net:[4026531840]",
+	} {
+		fsys.fs[name] = testLink(target)
+	}
+	fsys.fs["1/fdinfo/1"] = &fstest.MapFile{Data: []byte("pos:\t4096\nflags:\t02102001\nmnt_id:\t30\nino:\t777\n")}
+	fsys.fs["1/fdinfo/10"] = &fstest.MapFile{Data: []byte("pos:\t0\nflags:\t02200000\nmnt_id:\t30\n")}
+	fsys.fs["1/fdinfo/3"] = &fstest.MapFile{Data: []byte("pos:\t0\nflags:\t02000002\nmnt_id:\t9\n")}
+	p, err := NewSourceFS(fsys).ProcessByPid(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fds, err := p.FDs()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []struct {
+		fd    int
+		kind  FDKind
+		inode uint64
+		path  string
+	}{
+		{0, FDDevice, 0, "/dev/null"},
+		{1, FDFile, 777, "/var/log/syslog"},
+		{2, FDDevice, 0, "/dev/pts/0"},
+		{3, FDSocket, 12345, ""},
+		{4, FDPipe, 555, ""},
+		{5, FDEventfd, 0, ""},
+		{6, FDEpoll, 0, ""},
+		{7, FDTimerfd, 0, ""},
+		{8, FDInotify, 0, ""},
+		{9, FDMemfd, 0, ""},
+		{10, FDDir, 0, "/etc"},
+		{11, FDAnonInode, 0, ""},
+		{12, FDFile, 0, "/tmp/old"},
+		{13, FDUnknown, 0, ""},
+	}
+	if len(fds) != len(want) {
+		t.Fatalf("Got %d fds, want %d", len(fds), len(want))
+	}
+	for i, w := range want {
+		fd := fds[i]
+		if fd.FD != w.fd || fd.Kind != w.kind || fd.Inode != w.inode || fd.Path() != w.path {
+			t.Errorf("Got fd %d %v %d %q, want %d %v %d %q", fd.FD, fd.Kind, fd.Inode, fd.Path(), w.fd, w.kind, w.inode, w.path)
+		}
+	}
+	if fds[1].Pos != 4096 || fds[1].Flags&syscall.O_ACCMODE != syscall.O_WRONLY || fds[1].Flags&syscall.O_APPEND == 0 {
+		t.Errorf("Got pos %d flags %o", fds[1].Pos, fds[1].Flags)
+	}
+	if fds[3].Flags&syscall.O_ACCMODE != syscall.O_RDWR {
+		t.Errorf("Got socket flags %o", fds[3].Flags)
+	}
+	if s := FDEpoll.String(); s != "epoll" {
+		t.Errorf("Got %q, want epoll", s)
+	}
+
+	p, _ = NewSourceFS(fsys).ProcessByPid(200)
+	if _, err := p.FDs(); err != syscall.ESRCH {
+		t.Errorf("Got %v, want %v", err, syscall.ESRCH)
+	}
+}
+
+func TestFDsLive(t *testing.T) {
+	f, err := os.Open("/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	defer w.Close()
+	fifo := t.TempDir() + "/fifo"
+	if err := syscall.Mkfifo(fifo, 0600); err != nil {
+		t.Fatal(err)
+	}
+	// Opening a FIFO for reading and writing does not block on linux.
+	ff, err := os.OpenFile(fifo, os.O_RDWR, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ff.Close()
+	null, err := os.Open(os.DevNull)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer null.Close()
+
+	fds, err := (&Process{ID: mypid}).FDs()
+	if err != nil {
+		t.Fatal(err)
+	}
+	kinds := map[int]FDKind{}
+	for _, fd := range fds {
+		kinds[fd.FD] = fd.Kind
+	}
+	if k := kinds[int(f.Fd())]; k != FDDir {
+		t.Errorf("Got kind %v for /, want dir", k)
+	}
+	if k := kinds[int(r.Fd())]; k != FDPipe {
+		t.Errorf("Got kind %v for pipe, want pipe", k)
+	}
+	if k := kinds[int(ff.Fd())]; k != FDPipe {
+		t.Errorf("Got kind %v for FIFO, want pipe", k)
+	}
+	if k := kinds[int(null.Fd())]; k != FDDevice {
+		t.Errorf("Got kind %v for %s, want device", k, os.DevNull)
+	}
+}
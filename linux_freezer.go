@@ -0,0 +1,154 @@
+//go:build linux
+
+package ps
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"strings"
+	"time"
+)
+
+// freezeTimeout is how long to wait for a cgroup to become frozen.
+const freezeTimeout = 5 * time.Second
+
+// A freezer freezes and thaws a single cgroup through the v2 cgroup.freeze
+// file or the v1 freezer.state file.
+type freezer struct {
+	c      *Cgroupfs
+	v2     bool   // The cgroup is in the unified hierarchy
+	path   string // The path of the cgroup, e.g., "/system.slice/foo.service"
+	file   string // The file written to freeze and thaw the cgroup
+	on     string // The value that freezes the cgroup
+	off    string // The value that thaws the cgroup
+	frozen func() bool
+}
+
+// freezer returns the freezer for the cgroup p is a member of.  The unified
+// hierarchy is used if it supports freezing, otherwise the v1 freezer
+// hierarchy is used.
+func (c *Cgroupfs) freezer(p *Process) (*freezer, error) {
+	cgroups, err := p.Cgroups()
+	if err != nil {
+		return nil, err
+	}
+	for _, cg := range cgroups {
+		if !cg.IsV2() {
+			continue
+		}
+		for _, root := range []string{c.root, path.Join(c.root, "unified")} {
+			dir := path.Join(root, cg.Path)
+			if cg.Path == "/" || !c.exists(dir+"/cgroup.freeze") {
+				continue
+			}
+			return &freezer{
+				c:    c,
+				v2:   true,
+				path: cg.Path,
+				file: dir + "/cgroup.freeze",
+				on:   "1",
+				off:  "0",
+				frozen: func() bool {
+					events, err := c.readKeyed(dir + "/cgroup.events")
+					return err == nil && events["frozen"] == 1
+				},
+			}, nil
+		}
+	}
+	for _, cg := range cgroups {
+		if !cg.Has("freezer") || cg.Path == "/" {
+			continue
+		}
+		for _, mount := range []string{strings.Join(cg.Controllers, ","), "freezer"} {
+			file := path.Join(c.root, mount, cg.Path, "freezer.state")
+			if !c.exists(file) {
+				continue
+			}
+			return &freezer{
+				c:    c,
+				path: cg.Path,
+				file: file,
+				on:   "FROZEN",
+				off:  "THAWED",
+				frozen: func() bool {
+					data, err := c.readFile(file)
+					return err == nil && string(bytes.TrimSpace(data)) == "FROZEN"
+				},
+			}, nil
+		}
+	}
+	return nil, fmt.Errorf("no cgroup freezer for process %d", p.ID)
+}
+
+// freeze freezes the cgroup of f and waits for all of its processes to be
+// frozen.  The cgroup is thawed if it does not become frozen within
+// freezeTimeout.
+func (f *freezer) freeze() error {
+	if err := f.write(f.on); err != nil {
+		return err
+	}
+	interval := minPollInterval
+	for end := time.Now().Add(freezeTimeout); !f.frozen(); {
+		if time.Now().After(end) {
+			f.thaw()
+			return fmt.Errorf("cgroup %s did not freeze", f.path)
+		}
+		time.Sleep(interval)
+		if interval *= 2; interval > maxPollInterval {
+			interval = maxPollInterval
+		}
+	}
+	return nil
+}
+
+// thaw thaws the cgroup of f.
+func (f *freezer) thaw() error {
+	return f.write(f.off)
+}
+
+func (f *freezer) write(value string) error {
+	if f.c.fsys != nil {
+		return errors.New("cgroup file system is read only")
+	}
+	return ioutil.WriteFile(f.file, []byte(value+"\n"), 0644)
+}
+
+// contains reports whether the cgroup of f is, or is an ancestor of, cg.
+func (f *freezer) contains(cg Cgroup) bool {
+	if cg.IsV2() != f.v2 || !f.v2 && !cg.Has("freezer") {
+		return false
+	}
+	return cg.Path == f.path || strings.HasPrefix(cg.Path, f.path+"/")
+}
+
+// freezeCgroup freezes the cgroup pid is a member of, found in /sys/fs/cgroup,
+// and returns a function that thaws it.  The cgroup of the calling process is
+// never frozen.
+func freezeCgroup(pid int) (func() error, error) {
+	return defaultCgroupfs.freezeCgroup(&Process{ID: pid}, &Process{ID: os.Getpid()})
+}
+
+// freezeCgroup freezes the cgroup of p, which must not contain self.
+func (c *Cgroupfs) freezeCgroup(p, self *Process) (func() error, error) {
+	f, err := c.freezer(p)
+	if err != nil {
+		return nil, err
+	}
+	cgroups, err := self.Cgroups()
+	if err != nil {
+		return nil, err
+	}
+	for _, cg := range cgroups {
+		if f.contains(cg) {
+			return nil, fmt.Errorf("cgroup %s contains the calling process", f.path)
+		}
+	}
+	if err := f.freeze(); err != nil {
+		return nil, err
+	}
+	return f.thaw, nil
+}
@@ -0,0 +1,130 @@
+//go:build linux
+
+package ps
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestFreezeCgroup(t *testing.T) {
+	for _, tt := range []struct {
+		name   string
+		cgroup string            // The cgroup file of the process frozen
+		self   string            // The cgroup file of the caller
+		files  map[string]string // The files of the cgroup file system
+		file   string            // The file written
+		frozen string            // The contents of file when frozen
+		thawed string            // The contents of file when thawed
+		err    string
+	}{{
+		name:   "v2",
+		cgroup: "0::/test.scope\n",
+		self:   "0::/user.slice\n",
+		files: map[string]string{
+			"cgroup.controllers":       "cpu memory pids\n",
+			"test.scope/cgroup.freeze": "0\n",
+			"test.scope/cgroup.events": "populated 1\nfrozen 1\n",
+			"user.slice/cgroup.freeze": "0\n",
+			"user.slice/cgroup.events": "populated 1\nfrozen 0\n",
+			"test.scope/cgroup.procs":  "42\n",
+			"user.slice/cgroup.procs":  "1\n",
+		},
+		file:   "test.scope/cgroup.freeze",
+		frozen: "1\n",
+		thawed: "0\n",
+	}, {
+		name:   "hybrid",
+		cgroup: "4:freezer:/test\n0::/test.scope\n",
+		self:   "4:freezer:/\n0::/user.slice\n",
+		files: map[string]string{
+			"freezer/test/freezer.state":       "THAWED\n",
+			"unified/test.scope/cgroup.freeze": "0\n",
+			"unified/test.scope/cgroup.events": "populated 1\nfrozen 1\n",
+		},
+		file:   "unified/test.scope/cgroup.freeze",
+		frozen: "1\n",
+		thawed: "0\n",
+	}, {
+		name:   "v1",
+		cgroup: "4:freezer:/test\n1:name=systemd:/user.slice\n",
+		self:   "4:freezer:/\n1:name=systemd:/user.slice\n",
+		files: map[string]string{
+			"freezer/test/freezer.state": "THAWED\n",
+		},
+		file:   "freezer/test/freezer.state",
+		frozen: "FROZEN\n",
+		thawed: "THAWED\n",
+	}, {
+		name:   "self",
+		cgroup: "0::/user.slice\n",
+		self:   "0::/user.slice/session.scope\n",
+		files: map[string]string{
+			"user.slice/cgroup.freeze": "0\n",
+		},
+		err: "contains the calling process",
+	}, {
+		name:   "root",
+		cgroup: "0::/\n",
+		self:   "0::/user.slice\n",
+		files: map[string]string{
+			"cgroup.controllers": "cpu memory pids\n",
+		},
+		err: "no cgroup freezer",
+	}} {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			for name, data := range tt.files {
+				name = filepath.Join(dir, name)
+				if err := os.MkdirAll(filepath.Dir(name), 0755); err != nil {
+					t.Fatal(err)
+				}
+				if err := ioutil.WriteFile(name, []byte(data), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			fsys := testProcFS()
+			fsys.fs["1/cgroup"] = &fstest.MapFile{Data: []byte(tt.cgroup)}
+			fsys.fs["2/cgroup"] = &fstest.MapFile{Data: []byte(tt.self)}
+			src := NewSourceFS(fsys)
+			p, err := src.ProcessByPid(1)
+			if err != nil {
+				t.Fatal(err)
+			}
+			self, err := src.ProcessByPid(2)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			thaw, err := NewCgroupfs(dir).freezeCgroup(p, self)
+			if tt.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Fatalf("Got error %v, want %q", err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			check := func(want string) {
+				t.Helper()
+				data, err := ioutil.ReadFile(filepath.Join(dir, tt.file))
+				if err != nil {
+					t.Fatal(err)
+				}
+				if string(data) != want {
+					t.Errorf("Got %s containing %q, want %q", tt.file, data, want)
+				}
+			}
+			check(tt.frozen)
+			if err := thaw(); err != nil {
+				t.Fatal(err)
+			}
+			check(tt.thawed)
+		})
+	}
+}
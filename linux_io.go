@@ -0,0 +1,190 @@
+//go:build linux
+
+package ps
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// IO is the I/O done by a process, as found in /proc/PID/io.  The counters
+// include the I/O of all the threads of the process, including those that
+// have exited.
+// IO is only available on linux.
+type IO struct {
+	Rchar               uint64 // Bytes read by read(2) and similar calls
+	Wchar               uint64 // Bytes written by write(2) and similar calls
+	Syscr               uint64 // Number of read system calls
+	Syscw               uint64 // Number of write system calls
+	ReadBytes           uint64 // Bytes fetched from the storage layer
+	WriteBytes          uint64 // Bytes sent to the storage layer
+	CancelledWriteBytes uint64 // Bytes not written due to truncation of dirty pages
+}
+
+// IO returns the I/O counters of p.  Non-root users will receive an error
+// when requesting information about a process with a different UID.
+// IO is only available on linux.
+func (p *Process) IO() (*IO, error) {
+	data, err := p.source().readFile(p.dirname() + "/io")
+	if err != nil {
+		return nil, fixError(err)
+	}
+	io := &IO{}
+	for _, line := range bytes.Split(data, []byte{'\n'}) {
+		x := bytes.IndexByte(line, ':')
+		if x < 0 {
+			continue
+		}
+		v, err := strconv.ParseUint(string(bytes.TrimSpace(line[x+1:])), 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid io line %q", line)
+		}
+		switch string(line[:x]) {
+		case "rchar":
+			io.Rchar = v
+		case "wchar":
+			io.Wchar = v
+		case "syscr":
+			io.Syscr = v
+		case "syscw":
+			io.Syscw = v
+		case "read_bytes":
+			io.ReadBytes = v
+		case "write_bytes":
+			io.WriteBytes = v
+		case "cancelled_write_bytes":
+			io.CancelledWriteBytes = v
+		}
+	}
+	return io, nil
+}
+
+// An IOSnapshot records the I/O counters of each process at a point in time.
+// The I/O rates between two snapshots are reported by Since.
+// IOSnapshot is only available on linux.
+type IOSnapshot struct {
+	Time  time.Time // When the snapshot was taken
+	procs map[int]ioCounters
+}
+
+type ioCounters struct {
+	p         *Process
+	starttime uint64
+	io        IO
+}
+
+// An IORate is the I/O done by a process per second between two snapshots.
+// IORate is only available on linux.
+type IORate struct {
+	Process             *Process
+	Rchar               float64 // Bytes read per second
+	Wchar               float64 // Bytes written per second
+	Syscr               float64 // Read system calls per second
+	Syscw               float64 // Write system calls per second
+	ReadBytes           float64 // Bytes per second fetched from storage
+	WriteBytes          float64 // Bytes per second sent to storage
+	CancelledWriteBytes float64 // Bytes per second of cancelled writes
+
+	// New is set if the process was not in the earlier snapshot, either
+	// because it was started or because its PID was reused.  The rate of a
+	// new process is all the I/O it did divided by the interval.
+	New bool
+}
+
+// SnapshotIO returns a snapshot of the I/O counters of each process in s.
+// Processes that cannot be read, normally because they belong to a different
+// user, are not included.
+func (s *Source) SnapshotIO() (*IOSnapshot, error) {
+	procs, err := s.Processes(false)
+	if err != nil {
+		return nil, err
+	}
+	snap := &IOSnapshot{
+		Time:  time.Now(),
+		procs: make(map[int]ioCounters, len(procs)),
+	}
+	for _, p := range procs {
+		st, err := p.Stat()
+		if err != nil {
+			continue
+		}
+		io, err := p.IO()
+		if err != nil {
+			continue
+		}
+		snap.procs[p.ID] = ioCounters{
+			p:         p,
+			starttime: st.Starttime,
+			io:        *io,
+		}
+	}
+	return snap, nil
+}
+
+// SnapshotIO returns a snapshot of the I/O counters of each process on the
+// system.
+// SnapshotIO is only available on linux.
+func SnapshotIO() (*IOSnapshot, error) {
+	return defaultSource.SnapshotIO()
+}
+
+// Since returns the I/O rates of each process in cur between the earlier
+// snapshot prev and cur, sorted by process ID.  Processes that exited between
+// the snapshots are not reported.  A process whose PID was reused is reported
+// as a new process.
+func (cur *IOSnapshot) Since(prev *IOSnapshot) []IORate {
+	interval := cur.Time.Sub(prev.Time).Seconds()
+	if interval <= 0 {
+		return nil
+	}
+	rates := make([]IORate, 0, len(cur.procs))
+	for pid, c := range cur.procs {
+		r := IORate{Process: c.p}
+		var last IO
+		if p, ok := prev.procs[pid]; ok && p.starttime == c.starttime {
+			last = p.io
+		} else {
+			r.New = true
+		}
+		rate := func(cur, prev uint64) float64 {
+			return float64(cur-prev) / interval
+		}
+		r.Rchar = rate(c.io.Rchar, last.Rchar)
+		r.Wchar = rate(c.io.Wchar, last.Wchar)
+		r.Syscr = rate(c.io.Syscr, last.Syscr)
+		r.Syscw = rate(c.io.Syscw, last.Syscw)
+		r.ReadBytes = rate(c.io.ReadBytes, last.ReadBytes)
+		r.WriteBytes = rate(c.io.WriteBytes, last.WriteBytes)
+		r.CancelledWriteBytes = rate(c.io.CancelledWriteBytes, last.CancelledWriteBytes)
+		rates = append(rates, r)
+	}
+	sort.Slice(rates, func(i, j int) bool {
+		return rates[i].Process.ID < rates[j].Process.ID
+	})
+	return rates
+}
+
+// SampleIO takes two snapshots of s, interval apart, and returns the I/O
+// rates of each process between them.
+func (s *Source) SampleIO(interval time.Duration) ([]IORate, error) {
+	prev, err := s.SnapshotIO()
+	if err != nil {
+		return nil, err
+	}
+	time.Sleep(interval)
+	cur, err := s.SnapshotIO()
+	if err != nil {
+		return nil, err
+	}
+	return cur.Since(prev), nil
+}
+
+// SampleIO returns the I/O rates of each process on the system over the next
+// interval.
+// SampleIO is only available on linux.
+func SampleIO(interval time.Duration) ([]IORate, error) {
+	return defaultSource.SampleIO(interval)
+}
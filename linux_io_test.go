@@ -0,0 +1,132 @@
+//go:build linux
+
+package ps
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"syscall"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+// testIO returns the contents of an io file.
+func testIO(rchar, wchar, syscr, syscw, readBytes, writeBytes uint64) []byte {
+	return []byte(fmt.Sprintf("rchar: %d\nwchar: %d\nsyscr: %d\nsyscw: %d\nread_bytes: %d\nwrite_bytes: %d\ncancelled_write_bytes: 0\n",
+		rchar, wchar, syscr, syscw, readBytes, writeBytes))
+}
+
+func TestIO(t *testing.T) {
+	fsys := testProcFS()
+	fsys.fs["1/io"] = &fstest.MapFile{Data: []byte("rchar: 1\nwchar: 2\nsyscr: 3\nsyscw: 4\nread_bytes: 5\nwrite_bytes: 6\ncancelled_write_bytes: 7\n")}
+	fsys.fs["3/io"] = &fstest.MapFile{Data: []byte("rchar: x\n")}
+	fsys.deny["100/io"] = true
+	src := NewSourceFS(fsys)
+
+	p, err := src.ProcessByPid(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	io, err := p.IO()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := (IO{1, 2, 3, 4, 5, 6, 7}); *io != want {
+		t.Errorf("Got %+v, want %+v", *io, want)
+	}
+
+	p, err = src.ProcessByPid(3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := p.IO(); err == nil {
+		t.Errorf("Did not get an error for an invalid io file")
+	}
+
+	p, err = src.ProcessByPid(100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := p.IO(); err != syscall.EPERM {
+		t.Errorf("Got error %v, want %v", err, syscall.EPERM)
+	}
+}
+
+func TestIOSnapshot(t *testing.T) {
+	fs1 := testProcFS()
+	fs1.fs["1/io"] = &fstest.MapFile{Data: testIO(1000, 2000, 10, 20, 4096, 8192)}
+	fs1.fs["100/io"] = &fstest.MapFile{Data: testIO(500, 0, 5, 0, 0, 0)}
+	fs1.fs["200/io"] = &fstest.MapFile{Data: testIO(0, 0, 0, 0, 0, 0)}
+	first, err := NewSourceFS(fs1).SnapshotIO()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fs2 := testProcFS()
+	fs2.fs["1/io"] = &fstest.MapFile{Data: testIO(3000, 2000, 30, 20, 4096+8192, 8192)}
+	// 100 exited and its PID was reused.
+	fs2.fs["100/stat"].Data = testStat(100, "dd", 'R', 1, 0, 0, 350)
+	fs2.fs["100/io"] = &fstest.MapFile{Data: testIO(600, 600, 6, 6, 0, 0)}
+	// 200 exited.
+	delete(fs2.fs, "200/stat")
+	second, err := NewSourceFS(fs2).SnapshotIO()
+	if err != nil {
+		t.Fatal(err)
+	}
+	second.Time = first.Time.Add(2 * time.Second)
+
+	var got []string
+	for _, r := range second.Since(first) {
+		got = append(got, fmt.Sprintf("%d %g %g %g %g %g %g %v", r.Process.ID, r.Rchar, r.Wchar, r.Syscr, r.Syscw, r.ReadBytes, r.WriteBytes, r.New))
+	}
+	want := []string{
+		"1 1000 0 10 0 4096 0 false",
+		"100 300 300 3 3 0 0 true",
+	}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("Got rates %q, want %q", got, want)
+	}
+	if r := first.Since(first); r != nil {
+		t.Errorf("Got rates over empty interval: %v", r)
+	}
+}
+
+func TestSampleIO(t *testing.T) {
+	f, err := ioutil.TempFile("", "ps-io")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(f.Name())
+	defer f.Close()
+
+	done := make(chan struct{})
+	go func() {
+		buf := make([]byte, 4096)
+		for {
+			select {
+			case <-done:
+				return
+			default:
+			}
+			if _, err := f.WriteAt(buf, 0); err != nil {
+				return
+			}
+		}
+	}()
+	rates, err := SampleIO(200 * time.Millisecond)
+	close(done)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, r := range rates {
+		if r.Process.ID == mypid {
+			if r.Wchar <= 0 || r.Syscw <= 0 {
+				t.Errorf("Got write rate of %.0f bytes/s in %.0f calls/s", r.Wchar, r.Syscw)
+			}
+			return
+		}
+	}
+	t.Errorf("My PID was not found")
+}
@@ -0,0 +1,172 @@
+//go:build linux
+
+package ps
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"syscall"
+	"unsafe"
+)
+
+// A Resource is a resource whose use by a process is limited, as used by
+// getrlimit(2).
+// Resource is only available on linux.
+type Resource int
+
+const (
+	RLIMIT_CPU        = Resource(0)  // CPU time in seconds
+	RLIMIT_FSIZE      = Resource(1)  // Maximum file size in bytes
+	RLIMIT_DATA       = Resource(2)  // Maximum data segment size in bytes
+	RLIMIT_STACK      = Resource(3)  // Maximum stack size in bytes
+	RLIMIT_CORE       = Resource(4)  // Maximum core file size in bytes
+	RLIMIT_RSS        = Resource(5)  // Maximum resident set size in bytes
+	RLIMIT_NPROC      = Resource(6)  // Maximum number of processes
+	RLIMIT_NOFILE     = Resource(7)  // Maximum number of open files
+	RLIMIT_MEMLOCK    = Resource(8)  // Maximum locked memory in bytes
+	RLIMIT_AS         = Resource(9)  // Maximum address space in bytes
+	RLIMIT_LOCKS      = Resource(10) // Maximum number of file locks
+	RLIMIT_SIGPENDING = Resource(11) // Maximum number of pending signals
+	RLIMIT_MSGQUEUE   = Resource(12) // Maximum bytes in POSIX message queues
+	RLIMIT_NICE       = Resource(13) // Ceiling of the nice value, as 20 - nice
+	RLIMIT_RTPRIO     = Resource(14) // Maximum real-time priority
+	RLIMIT_RTTIME     = Resource(15) // Real-time CPU time in microseconds
+)
+
+// RLIM_INFINITY is the value of a limit that is unlimited.
+const RLIM_INFINITY = ^uint64(0)
+
+// limitNames are the names of the resources used in /proc/PID/limits.
+var limitNames = []string{
+	RLIMIT_CPU:        "Max cpu time",
+	RLIMIT_FSIZE:      "Max file size",
+	RLIMIT_DATA:       "Max data size",
+	RLIMIT_STACK:      "Max stack size",
+	RLIMIT_CORE:       "Max core file size",
+	RLIMIT_RSS:        "Max resident set",
+	RLIMIT_NPROC:      "Max processes",
+	RLIMIT_NOFILE:     "Max open files",
+	RLIMIT_MEMLOCK:    "Max locked memory",
+	RLIMIT_AS:         "Max address space",
+	RLIMIT_LOCKS:      "Max file locks",
+	RLIMIT_SIGPENDING: "Max pending signals",
+	RLIMIT_MSGQUEUE:   "Max msgqueue size",
+	RLIMIT_NICE:       "Max nice priority",
+	RLIMIT_RTPRIO:     "Max realtime priority",
+	RLIMIT_RTTIME:     "Max realtime timeout",
+}
+
+// String returns the name of r as used in /proc/PID/limits, e.g., "Max open
+// files".
+func (r Resource) String() string {
+	if r >= 0 && int(r) < len(limitNames) {
+		return limitNames[r]
+	}
+	return fmt.Sprintf("Resource(%d)", int(r))
+}
+
+// A Limit is the soft and hard limit of a resource.  An unlimited limit is
+// RLIM_INFINITY.
+// Limit is only available on linux.
+type Limit struct {
+	Soft uint64
+	Hard uint64
+}
+
+func (l Limit) String() string {
+	return limitString(l.Soft) + " " + limitString(l.Hard)
+}
+
+func limitString(v uint64) string {
+	if v == RLIM_INFINITY {
+		return "unlimited"
+	}
+	return strconv.FormatUint(v, 10)
+}
+
+// Limits returns the resource limits of p from /proc/PID/limits.  Resources
+// not listed by the kernel are not included.
+// Limits is only available on linux.
+func (p *Process) Limits() (map[Resource]Limit, error) {
+	data, err := p.source().readFile(p.dirname() + "/limits")
+	if err != nil {
+		return nil, fixError(err)
+	}
+	return parseLimits(data)
+}
+
+// parseLimits parses the contents of /proc/PID/limits, e.g.:
+//
+//	Limit                     Soft Limit           Hard Limit           Units
+//	Max cpu time              unlimited            unlimited            seconds
+//	Max open files            1024                 1048576              files
+func parseLimits(data []byte) (map[Resource]Limit, error) {
+	limits := map[Resource]Limit{}
+	for _, line := range bytes.Split(data, []byte{'\n'}) {
+		s := string(line)
+		if s == "" || strings.HasPrefix(s, "Limit ") {
+			continue
+		}
+		r := Resource(-1)
+		for i, name := range limitNames {
+			if strings.HasPrefix(s, name+" ") {
+				r = Resource(i)
+				break
+			}
+		}
+		if r < 0 {
+			continue // a resource added by a newer kernel
+		}
+		f := strings.Fields(s[len(limitNames[r]):])
+		if len(f) < 2 {
+			return nil, fmt.Errorf("invalid limits line %q", s)
+		}
+		var l Limit
+		var err error
+		if l.Soft, err = parseLimit(f[0]); err != nil {
+			return nil, fmt.Errorf("invalid limits line %q", s)
+		}
+		if l.Hard, err = parseLimit(f[1]); err != nil {
+			return nil, fmt.Errorf("invalid limits line %q", s)
+		}
+		limits[r] = l
+	}
+	return limits, nil
+}
+
+func parseLimit(s string) (uint64, error) {
+	if s == "unlimited" {
+		return RLIM_INFINITY, nil
+	}
+	return strconv.ParseUint(s, 10, 64)
+}
+
+// ErrNotLive is returned when attempting to change a process that was not
+// read from a live procfs, such as a process read from a capture.
+var ErrNotLive = errors.New("process is not from a live procfs")
+
+// SetLimit sets the soft and hard limits of resource for p using prlimit(2).
+// Raising the hard limit, or changing the limits of a process owned by a
+// different user, requires the CAP_SYS_RESOURCE capability.  The ID of p must
+// be valid in the pid namespace of the caller.  ErrNotLive is returned if p
+// was read from a Source created by NewSourceFS.
+// SetLimit is only available on linux.
+func (p *Process) SetLimit(resource Resource, soft, hard uint64) error {
+	if p.source().fsys != nil {
+		return ErrNotLive
+	}
+	return prlimit(p.ID, resource, &Limit{Soft: soft, Hard: hard}, nil)
+}
+
+// prlimit calls prlimit64(2).  Limit has the same layout as struct rlimit64.
+func prlimit(pid int, resource Resource, newLimit, oldLimit *Limit) error {
+	_, _, e := syscall.RawSyscall6(syscall.SYS_PRLIMIT64, uintptr(pid), uintptr(resource),
+		uintptr(unsafe.Pointer(newLimit)), uintptr(unsafe.Pointer(oldLimit)), 0, 0)
+	if e != 0 {
+		return e
+	}
+	return nil
+}
@@ -0,0 +1,121 @@
+//go:build linux
+
+package ps
+
+import (
+	"syscall"
+	"testing"
+	"testing/fstest"
+)
+
+const testLimits = `Limit                     Soft Limit           Hard Limit           Units     
+Max cpu time              unlimited            unlimited            seconds   
+Max file size             unlimited            unlimited            bytes     
+Max data size             unlimited            unlimited            bytes     
+Max stack size            8388608              unlimited            bytes     
+Max core file size        0                    unlimited            bytes     
+Max resident set          unlimited            unlimited            bytes     
+Max processes             63704                63704                processes 
+Max open files            1024                 1048576              files     
+Max locked memory         8388608              8388608              bytes     
+Max address space         unlimited            unlimited            bytes     
+Max file locks            unlimited            unlimited            locks     
+Max pending signals       63704                63704                signals   
+Max msgqueue size         819200               819200               bytes     
+Max nice priority         0                    0                    
+Max realtime priority     0                    0                    
+Max realtime timeout      unlimited            unlimited            us        
+Max future thing          1                    2                    things    
+`
+
+func TestLimits(t *testing.T) {
+	fsys := testProcFS()
+	fsys.fs["1/limits"] = &fstest.MapFile{Data: []byte(testLimits)}
+	fsys.fs["3/limits"] = &fstest.MapFile{Data: []byte("Max open files            lots                 1048576              files\n")}
+	src := NewSourceFS(fsys)
+
+	p, err := src.ProcessByPid(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	limits, err := p.Limits()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(limits) != 16 {
+		t.Errorf("Got %d limits, want 16", len(limits))
+	}
+	for _, tt := range []struct {
+		r    Resource
+		want Limit
+	}{
+		{RLIMIT_CPU, Limit{RLIM_INFINITY, RLIM_INFINITY}},
+		{RLIMIT_STACK, Limit{8388608, RLIM_INFINITY}},
+		{RLIMIT_CORE, Limit{0, RLIM_INFINITY}},
+		{RLIMIT_NOFILE, Limit{1024, 1048576}},
+		{RLIMIT_NICE, Limit{0, 0}},
+		{RLIMIT_RTTIME, Limit{RLIM_INFINITY, RLIM_INFINITY}},
+	} {
+		if got := limits[tt.r]; got != tt.want {
+			t.Errorf("%v: got %v, want %v", tt.r, got, tt.want)
+		}
+	}
+	if s := limits[RLIMIT_STACK].String(); s != "8388608 unlimited" {
+		t.Errorf("Got %q, want %q", s, "8388608 unlimited")
+	}
+	if s := RLIMIT_NOFILE.String(); s != "Max open files" {
+		t.Errorf("Got %q, want %q", s, "Max open files")
+	}
+	if s := Resource(99).String(); s != "Resource(99)" {
+		t.Errorf("Got %q, want %q", s, "Resource(99)")
+	}
+
+	p, err = src.ProcessByPid(3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := p.Limits(); err == nil {
+		t.Errorf("Did not get an error for an invalid limits file")
+	}
+
+	if err := p.SetLimit(RLIMIT_NOFILE, 1, 1); err != ErrNotLive {
+		t.Errorf("Got error %v, want %v", err, ErrNotLive)
+	}
+}
+
+func TestLimitsLive(t *testing.T) {
+	var rlim syscall.Rlimit
+	if err := syscall.Getrlimit(syscall.RLIMIT_NOFILE, &rlim); err != nil {
+		t.Fatal(err)
+	}
+	p := &Process{ID: mypid}
+	limits, err := p.Limits()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := limits[RLIMIT_NOFILE], (Limit{rlim.Cur, rlim.Max}); got != want {
+		t.Fatalf("Got %v, want %v", got, want)
+	}
+	if rlim.Cur < 2 {
+		t.Skipf("open file limit of %d is too low", rlim.Cur)
+	}
+
+	if err := p.SetLimit(RLIMIT_NOFILE, rlim.Cur-1, rlim.Max); err != nil {
+		t.Fatal(err)
+	}
+	defer p.SetLimit(RLIMIT_NOFILE, rlim.Cur, rlim.Max)
+	limits, err = p.Limits()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := limits[RLIMIT_NOFILE], (Limit{rlim.Cur - 1, rlim.Max}); got != want {
+		t.Errorf("After SetLimit got %v, want %v", got, want)
+	}
+
+	if err := p.SetLimit(RLIMIT_NOFILE, rlim.Max, rlim.Max-1); err != syscall.EINVAL {
+		t.Errorf("Soft limit above hard limit got %v, want %v", err, syscall.EINVAL)
+	}
+	if err := (&Process{ID: 1234567}).SetLimit(RLIMIT_NOFILE, rlim.Cur, rlim.Max); err != syscall.ESRCH {
+		t.Errorf("Invalid PID got %v, want %v", err, syscall.ESRCH)
+	}
+}
@@ -0,0 +1,160 @@
+//go:build linux
+
+package ps
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// A Mapping is a memory mapped region of a process as found in
+// /proc/PID/maps.
+// Mapping is only available on linux.
+type Mapping struct {
+	Start  uint64 // Start address of the region
+	End    uint64 // Address just past the end of the region
+	Perms  string // Permissions, e.g., "r-xp"
+	Offset uint64 // Offset into the mapped file
+	Major  int    // Major device number of the mapped file
+	Minor  int    // Minor device number of the mapped file
+	Inode  uint64 // Inode of the mapped file, 0 if none
+	Path   string // Path of the mapped file or a pseudo-path such as "[heap]"
+}
+
+// Maps returns the memory mappings of p from /proc/PID/maps.  Non-root users
+// will receive an error when requesting information about a process with a
+// different UID.
+// Maps is only available on linux.
+func (p *Process) Maps() ([]Mapping, error) {
+	data, err := p.source().readFile(p.dirname() + "/maps")
+	if err != nil {
+		return nil, fixError(err)
+	}
+	var maps []Mapping
+	for _, line := range bytes.Split(data, []byte{'\n'}) {
+		if len(line) == 0 {
+			continue
+		}
+		m, err := parseMapping(string(line))
+		if err != nil {
+			return nil, err
+		}
+		maps = append(maps, m)
+	}
+	return maps, nil
+}
+
+// parseMapping parses a single line of /proc/PID/maps.
+func parseMapping(line string) (Mapping, error) {
+	var m Mapping
+	next := func() string {
+		line = strings.TrimLeft(line, " ")
+		x := strings.IndexByte(line, ' ')
+		if x < 0 {
+			x = len(line)
+		}
+		f := line[:x]
+		line = line[x:]
+		return f
+	}
+	orig := line
+	bad := func() (Mapping, error) {
+		return Mapping{}, fmt.Errorf("invalid mapping: %q", orig)
+	}
+	addrs := next()
+	x := strings.IndexByte(addrs, '-')
+	if x < 0 {
+		return bad()
+	}
+	var err error
+	if m.Start, err = strconv.ParseUint(addrs[:x], 16, 64); err != nil {
+		return bad()
+	}
+	if m.End, err = strconv.ParseUint(addrs[x+1:], 16, 64); err != nil {
+		return bad()
+	}
+	m.Perms = next()
+	if m.Offset, err = strconv.ParseUint(next(), 16, 64); err != nil {
+		return bad()
+	}
+	dev := next()
+	x = strings.IndexByte(dev, ':')
+	if x < 0 {
+		return bad()
+	}
+	major, err1 := strconv.ParseUint(dev[:x], 16, 32)
+	minor, err2 := strconv.ParseUint(dev[x+1:], 16, 32)
+	if err1 != nil || err2 != nil {
+		return bad()
+	}
+	m.Major, m.Minor = int(major), int(minor)
+	if m.Inode, err = strconv.ParseUint(next(), 10, 64); err != nil {
+		return bad()
+	}
+	m.Path = strings.TrimLeft(line, " ")
+	return m, nil
+}
+
+// Size returns the size of m in bytes.
+func (m Mapping) Size() uint64 {
+	return m.End - m.Start
+}
+
+// Readable returns true if m can be read.
+func (m Mapping) Readable() bool {
+	return len(m.Perms) > 0 && m.Perms[0] == 'r'
+}
+
+// Writable returns true if m can be written.
+func (m Mapping) Writable() bool {
+	return len(m.Perms) > 1 && m.Perms[1] == 'w'
+}
+
+// Executable returns true if m can be executed.
+func (m Mapping) Executable() bool {
+	return len(m.Perms) > 2 && m.Perms[2] == 'x'
+}
+
+// Shared returns true if m is shared rather than private (copy on write).
+func (m Mapping) Shared() bool {
+	return len(m.Perms) > 3 && m.Perms[3] == 's'
+}
+
+// IsHeap returns true if m is the heap of the process.
+func (m Mapping) IsHeap() bool {
+	return m.Path == "[heap]"
+}
+
+// IsStack returns true if m is the stack of the main thread (or, on older
+// kernels, of any thread).
+func (m Mapping) IsStack() bool {
+	return m.Path == "[stack]" || strings.HasPrefix(m.Path, "[stack:")
+}
+
+// IsVDSO returns true if m is the virtual dynamic shared object or its data.
+func (m Mapping) IsVDSO() bool {
+	return m.Path == "[vdso]" || m.Path == "[vvar]"
+}
+
+// IsAnonymous returns true if m is not backed by a file.  The heap, stacks
+// and vdso are all anonymous.
+func (m Mapping) IsAnonymous() bool {
+	return m.Inode == 0
+}
+
+// IsFileBacked returns true if m is backed by a file.  This includes deleted
+// files and memfd files.
+func (m Mapping) IsFileBacked() bool {
+	return m.Inode != 0
+}
+
+// Deleted returns true if the file backing m has been deleted.
+func (m Mapping) Deleted() bool {
+	return m.IsFileBacked() && strings.HasSuffix(m.Path, " (deleted)")
+}
+
+func (m Mapping) String() string {
+	return fmt.Sprintf("%x-%x %s %08x %02x:%02x %d %s", m.Start, m.End, m.Perms, m.Offset, m.Major, m.Minor, m.Inode, m.Path)
+}
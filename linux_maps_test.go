@@ -0,0 +1,101 @@
+//go:build linux
+
+package ps
+
+import (
+	"testing"
+	"testing/fstest"
+)
+
+const testMaps = `55d0c4a5e000-55d0c4a60000 r--p 00000000 08:01 1234                       /usr/bin/cat
+55d0c4a60000-55d0c4a65000 r-xp 00002000 08:01 1234                       /usr/bin/cat
+55d0c5e1c000-55d0c5e3d000 rw-p 00000000 00:00 0                          [heap]
+7f1e2a000000-7f1e2a021000 rw-s 00000000 00:01 4321                       /memfd:my buffer (deleted)
+7f1e2b000000-7f1e2b001000 rw-p 00000000 00:00 0 
+7ffd1c1d4000-7ffd1c1f5000 rw-p 00000000 00:00 0                          [stack]
+7ffd1c1f8000-7ffd1c1fc000 r--p 00000000 00:00 0                          [vvar]
+7ffd1c1fc000-7ffd1c1fe000 r-xp 00000000 00:00 0                          [vdso]
+ffffffffff600000-ffffffffff601000 --xp 00000000 00:00 0                  [vsyscall]
+`
+
+func TestMaps(t *testing.T) {
+	fsys := testProcFS()
+	fsys.fs["1/maps"] = &fstest.MapFile{Data: []byte(testMaps)}
+	p, err := NewSourceFS(fsys).ProcessByPid(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	maps, err := p.Maps()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(maps) != 9 {
+		t.Fatalf("Got %d mappings, want 9", len(maps))
+	}
+	m := maps[1]
+	want := Mapping{
+		Start:  0x55d0c4a60000,
+		End:    0x55d0c4a65000,
+		Perms:  "r-xp",
+		Offset: 0x2000,
+		Major:  8,
+		Minor:  1,
+		Inode:  1234,
+		Path:   "/usr/bin/cat",
+	}
+	if m != want {
+		t.Errorf("Got %v, want %v", m, want)
+	}
+	if m.Size() != 0x5000 || !m.Readable() || m.Writable() || !m.Executable() || m.Shared() || !m.IsFileBacked() {
+		t.Errorf("Wrong attributes for %v", m)
+	}
+
+	for _, tt := range []struct {
+		i                                      int
+		heap, stack, vdso, anon, file, deleted bool
+	}{
+		{0, false, false, false, false, true, false},
+		{2, true, false, false, true, false, false},
+		{3, false, false, false, false, true, true},
+		{4, false, false, false, true, false, false},
+		{5, false, true, false, true, false, false},
+		{6, false, false, true, true, false, false},
+		{7, false, false, true, true, false, false},
+		{8, false, false, false, true, false, false},
+	} {
+		m := maps[tt.i]
+		if m.IsHeap() != tt.heap || m.IsStack() != tt.stack || m.IsVDSO() != tt.vdso ||
+			m.IsAnonymous() != tt.anon || m.IsFileBacked() != tt.file || m.Deleted() != tt.deleted {
+			t.Errorf("Wrong classification of %v", m)
+		}
+	}
+	if maps[3].Path != "/memfd:my buffer (deleted)" || !maps[3].Shared() {
+		t.Errorf("Got %v", maps[3])
+	}
+	if maps[4].Path != "" {
+		t.Errorf("Got path %q for anonymous mapping", maps[4].Path)
+	}
+
+	fsys.fs["1/maps"] = &fstest.MapFile{Data: []byte("55d0c4a5e000 r--p 00000000 08:01 1234\n")}
+	p, _ = NewSourceFS(fsys).ProcessByPid(1)
+	if _, err := p.Maps(); err == nil {
+		t.Errorf("Invalid maps did not return an error")
+	}
+}
+
+func TestMapsLive(t *testing.T) {
+	p := &Process{ID: mypid}
+	maps, err := p.Maps()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var stack, exe bool
+	path, _ := p.Path()
+	for _, m := range maps {
+		stack = stack || m.IsStack()
+		exe = exe || (m.Path == path && m.Executable())
+	}
+	if !stack || !exe {
+		t.Errorf("stack %v, executable %v", stack, exe)
+	}
+}
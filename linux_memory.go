@@ -0,0 +1,199 @@
+//go:build linux
+
+package ps
+
+import (
+	"bytes"
+	"os"
+	"strconv"
+)
+
+// footprint returns the physical memory used by p.  It is the proportional set
+// size, including swap, from /proc/PID/smaps_rollup.  If smaps_rollup cannot be
+// read (it requires ptrace access to p) it is the resident anonymous memory and
+// swap from /proc/PID/status.  smaps_rollup is not cached and is read on every
+// call, so refresh only applies to the status fallback.
+func (p *Process) footprint(refresh ...bool) (int, error) {
+	if md, err := p.MemoryDetail(false); err == nil {
+		return int(md.Pss + md.SwapPss), nil
+	}
+	sm, err := p.StatusMap(refresh...)
+	if err != nil {
+		return 0, err
+	}
+	var total int64
+	for _, name := range []string{"RssAnon", "VmSwap"} {
+		v, ok := sm[name]
+		if !ok {
+			continue
+		}
+		n, err := v.AsSize("")
+		if err != nil {
+			return 0, err
+		}
+		total += n
+	}
+	return int(total), nil
+}
+
+// VirtualSize returns the size of the virtual address space of p in bytes.
+// This is usually much larger than the memory actually used by p, see
+// Footprint and ResidentSize.
+// Pass in the value "true" to refresh the information.
+// VirtualSize is only available on linux.
+func (p *Process) VirtualSize(refresh ...bool) (int, error) {
+	s, err := p.Stat(refresh...)
+	if err != nil {
+		return 0, err
+	}
+	return int(s.Vsize), nil
+}
+
+// ResidentSize returns the resident set size (RSS) of p in bytes.  Pages
+// shared with other processes, such as those of shared libraries, are counted
+// in full.
+// Pass in the value "true" to refresh the information.
+// ResidentSize is only available on linux.
+func (p *Process) ResidentSize(refresh ...bool) (int, error) {
+	s, err := p.Stat(refresh...)
+	if err != nil {
+		return 0, err
+	}
+	return int(s.Rss) * os.Getpagesize(), nil
+}
+
+// MemoryUsage is the memory used by a process, or one of its mappings, as
+// reported by /proc/PID/smaps.  All values are in bytes.
+// MemoryUsage is only available on linux.
+type MemoryUsage struct {
+	Rss          int64 // Resident set size
+	Pss          int64 // Proportional set size
+	PssAnon      int64 // Proportional set size of anonymous memory
+	PssFile      int64 // Proportional set size of file backed memory
+	PssShmem     int64 // Proportional set size of shared memory
+	SharedClean  int64 // Clean pages shared with other processes
+	SharedDirty  int64 // Dirty pages shared with other processes
+	PrivateClean int64 // Clean pages only used by this process
+	PrivateDirty int64 // Dirty pages only used by this process
+	Swap         int64 // Swapped out anonymous memory
+	SwapPss      int64 // Proportional swap usage
+}
+
+// Uss returns the unique set size of u, the memory that would be freed if
+// the process exited.
+func (u *MemoryUsage) Uss() int64 {
+	return u.PrivateClean + u.PrivateDirty
+}
+
+// add adds size to the field of u associated with name, as found in
+// /proc/PID/smaps.  Unknown names are ignored.
+func (u *MemoryUsage) add(name string, size int64) {
+	switch name {
+	case "Rss":
+		u.Rss += size
+	case "Pss":
+		u.Pss += size
+	case "Pss_Anon":
+		u.PssAnon += size
+	case "Pss_File":
+		u.PssFile += size
+	case "Pss_Shmem":
+		u.PssShmem += size
+	case "Shared_Clean":
+		u.SharedClean += size
+	case "Shared_Dirty":
+		u.SharedDirty += size
+	case "Private_Clean":
+		u.PrivateClean += size
+	case "Private_Dirty":
+		u.PrivateDirty += size
+	case "Swap":
+		u.Swap += size
+	case "SwapPss":
+		u.SwapPss += size
+	}
+}
+
+// A MappingMemory is the memory used by a single mapping of a process.
+// MappingMemory is only available on linux.
+type MappingMemory struct {
+	Mapping
+	MemoryUsage
+}
+
+// A MemoryDetail is the memory used by a process.  Mappings is only filled in
+// when requested.
+// MemoryDetail is only available on linux.
+type MemoryDetail struct {
+	MemoryUsage
+	Mappings []MappingMemory
+}
+
+// MemoryDetail returns the memory used by p.  The totals are read from
+// /proc/PID/smaps_rollup or, if it is not available or mappings is true, are
+// summed from /proc/PID/smaps.  If mappings is true then the usage of each
+// mapping is also returned.  Non-root users will receive an error when
+// requesting information about a process with a different UID.
+// MemoryDetail is only available on linux.
+func (p *Process) MemoryDetail(mappings bool) (*MemoryDetail, error) {
+	if !mappings {
+		data, err := p.source().readFile(p.dirname() + "/smaps_rollup")
+		if err == nil {
+			var md MemoryDetail
+			for _, line := range bytes.Split(data, []byte{'\n'}) {
+				if name, size, ok := parseSizeLine(line); ok {
+					md.add(name, size)
+				}
+			}
+			return &md, nil
+		}
+	}
+	data, err := p.source().readFile(p.dirname() + "/smaps")
+	if err != nil {
+		return nil, fixError(err)
+	}
+	var md MemoryDetail
+	var mm *MappingMemory
+	for _, line := range bytes.Split(data, []byte{'\n'}) {
+		if len(line) == 0 {
+			continue
+		}
+		if name, size, ok := parseSizeLine(line); ok {
+			md.add(name, size)
+			if mm != nil {
+				mm.add(name, size)
+			}
+			continue
+		}
+		if f := bytes.Fields(line); len(f) == 0 || bytes.HasSuffix(f[0], []byte{':'}) {
+			continue // A field that is not a size, such as VmFlags.
+		}
+		m, err := parseMapping(string(line))
+		if err != nil {
+			return nil, err
+		}
+		if mappings {
+			md.Mappings = append(md.Mappings, MappingMemory{Mapping: m})
+			mm = &md.Mappings[len(md.Mappings)-1]
+		}
+	}
+	return &md, nil
+}
+
+// parseSizeLine parses a line of the form "Name:   1234 kB" returning the name
+// and size in bytes.
+func parseSizeLine(line []byte) (string, int64, bool) {
+	x := bytes.IndexByte(line, ':')
+	if x <= 0 {
+		return "", 0, false
+	}
+	f := bytes.Fields(line[x+1:])
+	if len(f) != 2 || string(f[1]) != "kB" {
+		return "", 0, false
+	}
+	v, err := strconv.ParseInt(string(f[0]), 10, 64)
+	if err != nil {
+		return "", 0, false
+	}
+	return string(line[:x]), v * 1024, true
+}
@@ -0,0 +1,177 @@
+//go:build linux
+
+package ps
+
+import (
+	"os"
+	"testing"
+	"testing/fstest"
+)
+
+func TestFootprintFS(t *testing.T) {
+	fsys := testProcFS()
+	fsys.fs["1/smaps_rollup"] = &fstest.MapFile{Data: []byte(
+		"55d0c0000000-7ffd00000000 ---p 00000000 00:00 0                          [rollup]\n" +
+			"Rss:               12000 kB\n" +
+			"Pss:                8000 kB\n" +
+			"Pss_Anon:           6000 kB\n" +
+			"Swap:                200 kB\n" +
+			"SwapPss:             100 kB\n")}
+	fsys.fs["100/status"].Data = append(fsys.fs["100/status"].Data,
+		"VmRSS:\t    5000 kB\nRssAnon:\t    3000 kB\nRssFile:\t    2000 kB\nVmSwap:\t      24 kB\n"...)
+	src := NewSourceFS(fsys)
+	for _, tt := range []struct {
+		pid  int
+		want int
+	}{
+		{1, 8100 * 1024},
+		{100, 3024 * 1024},
+		{200, 0},
+	} {
+		p, err := src.ProcessByPid(tt.pid)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := p.Footprint()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != tt.want {
+			t.Errorf("Process %d has footprint %d, want %d", tt.pid, got, tt.want)
+		}
+	}
+}
+
+func TestMemorySizes(t *testing.T) {
+	fsys := testProcFS()
+	fsys.fs["1/stat"].Data = []byte("1 (systemd) S 0 1 1 0 -1 4194560 0 0 0 0 100 50 0 0 20 0 1 0 1 1048576 10 0\n")
+	p, err := NewSourceFS(fsys).ProcessByPid(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if vsize, err := p.VirtualSize(); err != nil || vsize != 1048576 {
+		t.Errorf("Got virtual size %d, %v, want 1048576", vsize, err)
+	}
+	if rss, err := p.ResidentSize(); err != nil || rss != 10*os.Getpagesize() {
+		t.Errorf("Got resident size %d, %v, want %d", rss, err, 10*os.Getpagesize())
+	}
+
+	p = &Process{ID: mypid}
+	footprint, err := p.Footprint()
+	if err != nil {
+		t.Fatal(err)
+	}
+	vsize, err := p.VirtualSize()
+	if err != nil {
+		t.Fatal(err)
+	}
+	rss, err := p.ResidentSize()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if footprint <= 0 || rss <= 0 || footprint >= vsize || rss >= vsize {
+		t.Errorf("Got footprint %d, rss %d, vsize %d", footprint, rss, vsize)
+	}
+}
+
+const testSmaps = `55d0c4a5e000-55d0c4a60000 r--p 00000000 08:01 1234                       /usr/bin/cat
+Size:                  8 kB
+KernelPageSize:        4 kB
+Rss:                   8 kB
+Pss:                   4 kB
+Pss_File:              4 kB
+Shared_Clean:          8 kB
+Shared_Dirty:          0 kB
+Private_Clean:         0 kB
+Private_Dirty:         0 kB
+Swap:                  0 kB
+SwapPss:               0 kB
+VmFlags: rd mr mw me dw sd
+55d0c5e1c000-55d0c5e3d000 rw-p 00000000 00:00 0                          [heap]
+Size:                132 kB
+Rss:                 100 kB
+Pss:                 100 kB
+Pss_Anon:            100 kB
+Shared_Clean:          0 kB
+Shared_Dirty:          0 kB
+Private_Clean:         0 kB
+Private_Dirty:       100 kB
+Swap:                 32 kB
+SwapPss:              32 kB
+THPeligible:    0
+VmFlags: rd wr mr mw me ac sd
+`
+
+func TestMemoryDetail(t *testing.T) {
+	fsys := testProcFS()
+	fsys.fs["1/smaps"] = &fstest.MapFile{Data: []byte(testSmaps)}
+	p, err := NewSourceFS(fsys).ProcessByPid(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := MemoryUsage{
+		Rss:          108 * 1024,
+		Pss:          104 * 1024,
+		PssAnon:      100 * 1024,
+		PssFile:      4 * 1024,
+		SharedClean:  8 * 1024,
+		PrivateDirty: 100 * 1024,
+		Swap:         32 * 1024,
+		SwapPss:      32 * 1024,
+	}
+	// Without smaps_rollup the totals come from smaps.
+	md, err := p.MemoryDetail(false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if md.MemoryUsage != want || md.Mappings != nil {
+		t.Errorf("Got %+v, want %+v", md, want)
+	}
+	if md.Uss() != 100*1024 {
+		t.Errorf("Got USS %d, want %d", md.Uss(), 100*1024)
+	}
+
+	md, err = p.MemoryDetail(true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if md.MemoryUsage != want {
+		t.Errorf("Got %+v, want %+v", md.MemoryUsage, want)
+	}
+	if len(md.Mappings) != 2 {
+		t.Fatalf("Got %d mappings, want 2", len(md.Mappings))
+	}
+	heap := md.Mappings[1]
+	if !heap.IsHeap() || heap.Rss != 100*1024 || heap.Swap != 32*1024 || heap.Uss() != 100*1024 {
+		t.Errorf("Got heap %+v", heap)
+	}
+	if exe := md.Mappings[0]; exe.Path != "/usr/bin/cat" || exe.SharedClean != 8*1024 {
+		t.Errorf("Got %+v", exe)
+	}
+
+	fsys.fs["1/smaps_rollup"] = &fstest.MapFile{Data: []byte("Rss: 10 kB\nPss: 5 kB\n")}
+	p, _ = NewSourceFS(fsys).ProcessByPid(1)
+	if md, err = p.MemoryDetail(false); err != nil || md.Rss != 10*1024 || md.Pss != 5*1024 {
+		t.Errorf("Got %+v, %v", md, err)
+	}
+
+	p, _ = NewSourceFS(fsys).ProcessByPid(2)
+	if _, err := p.MemoryDetail(false); err == nil {
+		t.Errorf("Got memory detail for kernel thread")
+	}
+}
+
+func TestMemoryDetailLive(t *testing.T) {
+	p := &Process{ID: mypid}
+	rollup, err := p.MemoryDetail(false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	md, err := p.MemoryDetail(true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rollup.Rss <= 0 || md.Rss <= 0 || len(md.Mappings) == 0 {
+		t.Errorf("Got rollup %+v, smaps %+v with %d mappings", rollup.MemoryUsage, md.MemoryUsage, len(md.Mappings))
+	}
+}
@@ -0,0 +1,302 @@
+//go:build linux
+
+package ps
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"strconv"
+	"unsafe"
+)
+
+// A Connection is a socket held open by a process, as found by joining the
+// socket inodes in /proc/PID/fd with the tables in /proc/PID/net.
+// Connection is only available on linux.
+type Connection struct {
+	FD         int    // The file descriptor of the socket
+	Proto      string // "tcp", "tcp6", "udp", "udp6" or "unix"
+	LocalAddr  net.IP // Not set for unix sockets
+	LocalPort  int
+	RemoteAddr net.IP // Not set for unix sockets
+	RemotePort int
+	State      string // E.g., "LISTEN" or "ESTABLISHED"
+	Inode      uint64 // The inode of the socket
+	Path       string // The bound path of a unix socket
+}
+
+func (c *Connection) String() string {
+	if c.Proto == "unix" {
+		return fmt.Sprintf("unix %s %s", c.Path, c.State)
+	}
+	return fmt.Sprintf("%s %s->%s %s", c.Proto,
+		net.JoinHostPort(c.LocalAddr.String(), strconv.Itoa(c.LocalPort)),
+		net.JoinHostPort(c.RemoteAddr.String(), strconv.Itoa(c.RemotePort)),
+		c.State)
+}
+
+// tcpStates are the names of the states found in /proc/net/tcp.
+var tcpStates = []string{
+	1:  "ESTABLISHED",
+	2:  "SYN_SENT",
+	3:  "SYN_RECV",
+	4:  "FIN_WAIT1",
+	5:  "FIN_WAIT2",
+	6:  "TIME_WAIT",
+	7:  "CLOSE",
+	8:  "CLOSE_WAIT",
+	9:  "LAST_ACK",
+	10: "LISTEN",
+	11: "CLOSING",
+	12: "NEW_SYN_RECV",
+}
+
+// unixStates are the names of the socket states found in /proc/net/unix.
+var unixStates = []string{
+	0: "FREE",
+	1: "UNCONNECTED",
+	2: "CONNECTING",
+	3: "CONNECTED",
+	4: "DISCONNECTING",
+}
+
+// unixAcceptCon is the flag set in /proc/net/unix for listening sockets.
+const unixAcceptCon = 0x10000
+
+// A netTable maps socket inodes to the connections found in /proc/PID/net.
+type netTable map[uint64]Connection
+
+// Connections returns the sockets p has open, sorted by file descriptor.
+// Sockets that are not internet or unix domain sockets, such as netlink
+// sockets, are not returned.  Non-root users will receive an error when
+// requesting information about a process with a different UID.  Use a
+// ConnectionCache to find the connections of many processes.
+// Connections is only available on linux.
+func (p *Process) Connections() ([]Connection, error) {
+	return (&ConnectionCache{}).Connections(p, nil)
+}
+
+// A ConnectionCache holds the network tables of each network namespace read
+// by its Connections method, so that finding the connections of many
+// processes reads and parses the tables of each namespace only once.  Sockets
+// created after the tables of their namespace were read are not found.  The
+// zero value is an empty cache.
+// ConnectionCache is only available on linux.
+type ConnectionCache struct {
+	tables map[string]netTable
+}
+
+// Connections returns the sockets p has open, as Process.Connections does,
+// reading the network tables of p's namespace from c if they are cached.
+// fds, if not nil, are the file descriptors of p as returned by p.FDs, which
+// saves them from being read again.
+func (c *ConnectionCache) Connections(p *Process, fds []FD) ([]Connection, error) {
+	if fds == nil {
+		var err error
+		if fds, err = p.FDs(); err != nil {
+			return nil, err
+		}
+	}
+	var table netTable
+	var conns []Connection
+	for _, fd := range fds {
+		if fd.Kind != FDSocket {
+			continue
+		}
+		if table == nil {
+			var err error
+			if table, err = c.netTable(p); err != nil {
+				return nil, err
+			}
+		}
+		conn, ok := table[fd.Inode]
+		if !ok {
+			continue
+		}
+		conn.FD = fd.FD
+		conns = append(conns, conn)
+	}
+	return conns, nil
+}
+
+// netTable returns the sockets in the network namespace of p.  Tables are
+// cached by namespace.  The tables of a process whose namespace cannot be
+// read are not cached.
+func (c *ConnectionCache) netTable(p *Process) (netTable, error) {
+	src := p.source()
+	ns, err := src.readlink(p.dirname() + "/ns/net")
+	if err == nil && c.tables[ns] != nil {
+		return c.tables[ns], nil
+	}
+	table := netTable{}
+	for _, proto := range []string{"tcp", "tcp6", "udp", "udp6"} {
+		data, err := src.readFile(p.dirname() + "/net/" + proto)
+		if err != nil {
+			continue // e.g., IPv6 is disabled
+		}
+		if err := table.parseInet(proto, data); err != nil {
+			return nil, err
+		}
+	}
+	if data, err := src.readFile(p.dirname() + "/net/unix"); err == nil {
+		if err := table.parseUnix(data); err != nil {
+			return nil, err
+		}
+	}
+	if ns != "" {
+		if c.tables == nil {
+			c.tables = map[string]netTable{}
+		}
+		c.tables[ns] = table
+	}
+	return table, nil
+}
+
+// parseInet adds the sockets from data, the contents of /proc/net/PROTO, to t.
+func (t netTable) parseInet(proto string, data []byte) error {
+	lines := bytes.Split(data, []byte{'\n'})
+	for _, line := range lines[1:] {
+		f := bytes.Fields(line)
+		if len(f) < 10 {
+			continue
+		}
+		c := Connection{Proto: proto}
+		var err error
+		if c.LocalAddr, c.LocalPort, err = parseHexAddr(string(f[1])); err != nil {
+			return err
+		}
+		if c.RemoteAddr, c.RemotePort, err = parseHexAddr(string(f[2])); err != nil {
+			return err
+		}
+		state, err := strconv.ParseUint(string(f[3]), 16, 8)
+		if err != nil {
+			return fmt.Errorf("invalid state in %s: %q", proto, f[3])
+		}
+		if int(state) < len(tcpStates) && tcpStates[state] != "" {
+			c.State = tcpStates[state]
+		} else {
+			c.State = strconv.Itoa(int(state))
+		}
+		if c.Inode, err = strconv.ParseUint(string(f[9]), 10, 64); err != nil {
+			return fmt.Errorf("invalid inode in %s: %q", proto, f[9])
+		}
+		t[c.Inode] = c
+	}
+	return nil
+}
+
+// parseUnix adds the sockets from data, the contents of /proc/net/unix, to t.
+func (t netTable) parseUnix(data []byte) error {
+	lines := bytes.Split(data, []byte{'\n'})
+	for _, line := range lines[1:] {
+		// Num RefCount Protocol Flags Type St Inode Path
+		f := bytes.Fields(line)
+		if len(f) < 7 {
+			continue
+		}
+		c := Connection{Proto: "unix"}
+		flags, err := strconv.ParseUint(string(f[3]), 16, 32)
+		if err != nil {
+			return fmt.Errorf("invalid flags in unix: %q", f[3])
+		}
+		state, err := strconv.ParseUint(string(f[5]), 16, 8)
+		if err != nil {
+			return fmt.Errorf("invalid state in unix: %q", f[5])
+		}
+		switch {
+		case flags&unixAcceptCon != 0:
+			c.State = "LISTEN"
+		case int(state) < len(unixStates):
+			c.State = unixStates[state]
+		default:
+			c.State = strconv.Itoa(int(state))
+		}
+		if c.Inode, err = strconv.ParseUint(string(f[6]), 10, 64); err != nil {
+			return fmt.Errorf("invalid inode in unix: %q", f[6])
+		}
+		if len(f) > 7 {
+			c.Path = string(f[7])
+		}
+		t[c.Inode] = c
+	}
+	return nil
+}
+
+// nativeEndian is the byte order of the host.  The kernel writes addresses
+// in /proc/net as 32 bit words in host byte order.
+var nativeEndian binary.ByteOrder = func() binary.ByteOrder {
+	x := uint16(1)
+	if *(*byte)(unsafe.Pointer(&x)) == 1 {
+		return binary.LittleEndian
+	}
+	return binary.BigEndian
+}()
+
+// parseHexAddr parses an address of the form "0100007F:0050" as found in
+// /proc/net/tcp.
+func parseHexAddr(s string) (net.IP, int, error) {
+	x := len(s) - 5
+	if x < 0 || s[x] != ':' {
+		return nil, 0, fmt.Errorf("invalid address: %q", s)
+	}
+	port, err := strconv.ParseUint(s[x+1:], 16, 16)
+	if err != nil {
+		return nil, 0, fmt.Errorf("invalid address: %q", s)
+	}
+	raw, err := hex.DecodeString(s[:x])
+	if err != nil || (len(raw) != net.IPv4len && len(raw) != net.IPv6len) {
+		return nil, 0, fmt.Errorf("invalid address: %q", s)
+	}
+	ip := make(net.IP, len(raw))
+	for i := 0; i < len(raw); i += 4 {
+		nativeEndian.PutUint32(ip[i:], binary.BigEndian.Uint32(raw[i:]))
+	}
+	return ip, int(port), nil
+}
+
+// ProcessesListeningOn returns the processes in s that have a TCP socket
+// listening on port, or a UDP socket bound to port.  Processes whose file
+// descriptors cannot be read are skipped.
+func (s *Source) ProcessesListeningOn(port int) ([]*Process, error) {
+	procs, err := s.Processes(false)
+	if err != nil {
+		return nil, err
+	}
+	cache := &ConnectionCache{}
+	var listeners []*Process
+	for _, p := range procs {
+		conns, err := cache.Connections(p, nil)
+		if err != nil {
+			continue
+		}
+		for _, c := range conns {
+			if c.LocalPort == port && c.listening() {
+				listeners = append(listeners, p)
+				break
+			}
+		}
+	}
+	return listeners, nil
+}
+
+// ProcessesListeningOn returns the processes that have a TCP socket listening
+// on port, or a UDP socket bound to port.  Only processes whose file
+// descriptors can be read by the caller are returned.
+// ProcessesListeningOn is only available on linux.
+func ProcessesListeningOn(port int) ([]*Process, error) {
+	return defaultSource.ProcessesListeningOn(port)
+}
+
+// listening returns true if c is a TCP socket listening for connections or
+// an unconnected UDP socket.
+func (c *Connection) listening() bool {
+	switch c.Proto {
+	case "tcp", "tcp6":
+		return c.State == "LISTEN"
+	case "udp", "udp6":
+		return c.State == "CLOSE"
+	}
+	return false
+}
@@ -0,0 +1,178 @@
+//go:build linux
+
+package ps
+
+import (
+	"net"
+	"testing"
+	"testing/fstest"
+)
+
+const testNetTCP = `  sl  local_address rem_address   st tx_queue rx_queue tr tm->when retrnsmt   uid  timeout inode
+   0: 00000000:0016 00000000:0000 0A 00000000:00000000 00:00000000 00000000     0        0 12345 1 0000000000000000 100 0 0 10 0
+   1: 0100007F:0016 0100007F:A2C4 01 00000000:00000000 00:00000000 00000000     0        0 12346 1 0000000000000000 20 4 30 10 -1
+`
+
+const testNetTCP6 = `  sl  local_address                         remote_address                        st tx_queue rx_queue tr tm->when retrnsmt   uid  timeout inode
+   0: 00000000000000000000000001000000:1F90 00000000000000000000000000000000:0000 0A 00000000:00000000 00:00000000 00000000     0        0 12347 1 0000000000000000 100 0 0 10 0
+`
+
+const testNetUDP = `   sl  local_address rem_address   st tx_queue rx_queue tr tm->when retrnsmt   uid  timeout inode ref pointer drops
+  100: 3500007F:0035 00000000:0000 07 00000000:00000000 00:00000000 00000000   101        0 12348 2 0000000000000000 0
+`
+
+const testNetUnix = `Num       RefCount Protocol Flags    Type St Inode Path
+0000000000000000: 00000002 00000000 00010000 0001 01 12349 /run/systemd/notify
+0000000000000000: 00000003 00000000 00000000 0001 03 12350
+`
+
+// testNetFS returns testProcFS with network tables added for process 1, which
+// has a socket for every entry in the tables, and process 100, which has
+// process 1's connection to its ssh listener.
+func testNetFS() denyFS {
+	fsys := testProcFS()
+	for i, inode := range []string{"12346", "12347", "12348", "12349", "12350", "99999"} {
+		fsys.fs["1/fd/"+string(rune('4'+i))] = testLink("socket:[" + inode + "]")
+	}
+	fsys.fs["1/ns/net"] = testLink("net:[4026531840]")
+	fsys.fs["100/fd/3"] = testLink("socket:[12346]")
+	fsys.fs["100/ns/net"] = testLink("net:[4026531840]")
+	for _, pid := range []string{"1", "100"} {
+		fsys.fs[pid+"/net/tcp"] = &fstest.MapFile{Data: []byte(testNetTCP)}
+		fsys.fs[pid+"/net/tcp6"] = &fstest.MapFile{Data: []byte(testNetTCP6)}
+		fsys.fs[pid+"/net/udp"] = &fstest.MapFile{Data: []byte(testNetUDP)}
+		fsys.fs[pid+"/net/unix"] = &fstest.MapFile{Data: []byte(testNetUnix)}
+	}
+	return fsys
+}
+
+func TestConnections(t *testing.T) {
+	p, err := NewSourceFS(testNetFS()).ProcessByPid(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	conns, err := p.Connections()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []struct {
+		fd     int
+		s      string
+		inode  uint64
+		listen bool
+	}{
+		{3, "tcp 0.0.0.0:22->0.0.0.0:0 LISTEN", 12345, true},
+		{4, "tcp 127.0.0.1:22->127.0.0.1:41668 ESTABLISHED", 12346, false},
+		{5, "tcp6 [::1]:8080->[::]:0 LISTEN", 12347, true},
+		{6, "udp 127.0.0.53:53->0.0.0.0:0 CLOSE", 12348, true},
+		{7, "unix /run/systemd/notify LISTEN", 12349, false},
+		{8, "unix  CONNECTED", 12350, false},
+	}
+	if len(conns) != len(want) {
+		t.Fatalf("Got %d connections, want %d", len(conns), len(want))
+	}
+	for i, w := range want {
+		c := conns[i]
+		if c.FD != w.fd || c.String() != w.s || c.Inode != w.inode || c.listening() != w.listen {
+			t.Errorf("Got %d %q %d %v, want %d %q %d %v", c.FD, c.String(), c.Inode, c.listening(), w.fd, w.s, w.inode, w.listen)
+		}
+	}
+	if !conns[0].LocalAddr.Equal(net.IPv4zero) || !conns[2].LocalAddr.Equal(net.IPv6loopback) {
+		t.Errorf("Got addresses %v and %v", conns[0].LocalAddr, conns[2].LocalAddr)
+	}
+}
+
+func TestProcessesListeningOn(t *testing.T) {
+	src := NewSourceFS(testNetFS())
+	for _, tt := range []struct {
+		port int
+		pids []int
+	}{
+		{22, []int{1}},
+		{8080, []int{1}},
+		{53, []int{1}},
+		{41668, nil},
+		{80, nil},
+	} {
+		procs, err := src.ProcessesListeningOn(tt.port)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(procs) != len(tt.pids) || (len(procs) > 0 && procs[0].ID != tt.pids[0]) {
+			t.Errorf("Port %d got %d processes, want %v", tt.port, len(procs), tt.pids)
+		}
+	}
+}
+
+func TestConnectionCache(t *testing.T) {
+	fsys := testNetFS()
+	src := NewSourceFS(fsys)
+	p1, err := src.ProcessByPid(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p100, err := src.ProcessByPid(100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cache := &ConnectionCache{}
+	if conns, err := cache.Connections(p1, nil); err != nil || len(conns) != 6 {
+		t.Fatalf("Got %d connections, %v, want 6", len(conns), err)
+	}
+	// Process 100 shares the network namespace of 1 and is answered from
+	// the cache.
+	delete(fsys.fs, "100/net/tcp")
+	fds, err := p100.FDs()
+	if err != nil {
+		t.Fatal(err)
+	}
+	conns, err := cache.Connections(p100, fds)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(conns) != 1 || conns[0].FD != 3 || conns[0].String() != "tcp 127.0.0.1:22->127.0.0.1:41668 ESTABLISHED" {
+		t.Errorf("Got connections %v", conns)
+	}
+	if len(cache.tables) != 1 {
+		t.Errorf("Got %d cached tables, want 1", len(cache.tables))
+	}
+}
+
+func TestConnectionsLive(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	port := l.Addr().(*net.TCPAddr).Port
+	c, err := net.Dial("tcp", l.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	conns, err := (&Process{ID: mypid}).Connections()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var listen, dial bool
+	for _, c := range conns {
+		switch {
+		case c.LocalPort == port && c.State == "LISTEN":
+			listen = c.LocalAddr.Equal(net.IPv4(127, 0, 0, 1))
+		case c.RemotePort == port && c.State == "ESTABLISHED":
+			dial = true
+		}
+	}
+	if !listen || !dial {
+		t.Errorf("Found listener %v, dialer %v in %v", listen, dial, conns)
+	}
+
+	procs, err := ProcessesListeningOn(port)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(procs) != 1 || procs[0].ID != mypid {
+		t.Errorf("Got %d listeners on port %d", len(procs), port)
+	}
+}
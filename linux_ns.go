@@ -0,0 +1,136 @@
+//go:build linux
+
+package ps
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+// Namespaces are the inode numbers of the namespaces a process is a member
+// of, as found in /proc/PID/ns.  Two processes are in the same namespace if
+// they have the same inode number for it.  A namespace that is not supported
+// by the kernel, or that could not be read, is 0.
+// Namespaces is only available on linux.
+type Namespaces struct {
+	Cgroup uint64
+	IPC    uint64
+	Mnt    uint64
+	Net    uint64
+	PID    uint64
+	Time   uint64
+	User   uint64
+	UTS    uint64
+}
+
+// Namespaces returns the namespaces p is a member of.  Non-root users will
+// receive an error when requesting information about a process with a
+// different UID.
+// Namespaces is only available on linux.
+func (p *Process) Namespaces() (*Namespaces, error) {
+	src := p.source()
+	dir := p.dirname() + "/ns"
+	if _, err := src.readDirNames(dir); err != nil {
+		return nil, fixError(err)
+	}
+	ns := &Namespaces{}
+	for _, n := range []struct {
+		name  string
+		inode *uint64
+	}{
+		{"cgroup", &ns.Cgroup},
+		{"ipc", &ns.IPC},
+		{"mnt", &ns.Mnt},
+		{"net", &ns.Net},
+		{"pid", &ns.PID},
+		{"time", &ns.Time},
+		{"user", &ns.User},
+		{"uts", &ns.UTS},
+	} {
+		target, err := src.readlink(dir + "/" + n.name)
+		if err != nil {
+			continue
+		}
+		*n.inode, _ = parseNSLink(n.name, target)
+	}
+	return ns, nil
+}
+
+// parseNSLink returns the inode of the namespace link target, which has the
+// form "name:[inode]".
+func parseNSLink(name, target string) (uint64, error) {
+	s := strings.TrimPrefix(target, name+":[")
+	if s == target || !strings.HasSuffix(s, "]") {
+		return 0, fmt.Errorf("invalid namespace link %q", target)
+	}
+	return strconv.ParseUint(s[:len(s)-1], 10, 64)
+}
+
+// NSPids returns the process IDs of p in each of the pid namespaces it is a
+// member of, from the NSpid line of /proc/PID/status.  The first ID is the ID
+// of p in the pid namespace of the procfs p was read from, normally the host,
+// and the last is its ID in its own pid namespace.  A process that is not in
+// a nested pid namespace has a single ID.
+// NSPids is only available on linux.
+func (p *Process) NSPids() ([]int, error) {
+	v, err := p.StatusValue("NSpid")
+	if err != nil {
+		return nil, err
+	}
+	a, err := v.AsArray()
+	if err != nil {
+		return nil, err
+	}
+	if len(a) == 0 {
+		return nil, fmt.Errorf("empty NSpid for process %d", p.ID)
+	}
+	pids := make([]int, len(a))
+	for i, pid := range a {
+		pids[i] = int(pid)
+	}
+	return pids, nil
+}
+
+// NamespacePid returns the process ID of p in its own pid namespace, e.g., 1
+// for the init process of a container.
+// NamespacePid is only available on linux.
+func (p *Process) NamespacePid() (int, error) {
+	pids, err := p.NSPids()
+	if err != nil {
+		return 0, err
+	}
+	return pids[len(pids)-1], nil
+}
+
+// HostPid returns the process ID, in the pid namespace of s, of the process
+// that is pid in the pid namespace with the inode pidNS.  The process must be
+// a member of pidNS itself rather than of a namespace nested within it.
+// syscall.ESRCH is returned if there is no such process.
+func (s *Source) HostPid(pidNS uint64, pid int) (int, error) {
+	procs, err := s.Processes(false)
+	if err != nil {
+		return 0, err
+	}
+	for _, p := range procs {
+		target, err := s.readlink(p.dirname() + "/ns/pid")
+		if err != nil {
+			continue
+		}
+		if inode, err := parseNSLink("pid", target); err != nil || inode != pidNS {
+			continue
+		}
+		if nspid, err := p.NamespacePid(); err == nil && nspid == pid {
+			return p.ID, nil
+		}
+	}
+	return 0, syscall.ESRCH
+}
+
+// HostPid returns the process ID on the system of the process that is pid in
+// the pid namespace with the inode pidNS.  See Source.HostPid for details.
+// HostPid is only available on linux.
+func HostPid(pidNS uint64, pid int) (int, error) {
+	return defaultSource.HostPid(pidNS, pid)
+}
@@ -0,0 +1,195 @@
+//go:build linux
+
+package ps
+
+import (
+	"fmt"
+	"syscall"
+	"testing"
+	"testing/fstest"
+)
+
+const (
+	testHostPidNS      = uint64(4026531836)
+	testContainerPidNS = uint64(4026532400)
+)
+
+// testNSFS returns testProcFS with a container whose init is host PID 300 and
+// a child of it that is host PID 301.
+func testNSFS() denyFS {
+	fsys := testProcFS()
+	for _, n := range []string{"cgroup", "ipc", "mnt", "net", "user", "uts"} {
+		fsys.fs["1/ns/"+n] = testLink(fmt.Sprintf("%s:[4026531840]", n))
+	}
+	fsys.fs["1/ns/pid"] = testLink(fmt.Sprintf("pid:[%d]", testHostPidNS))
+	fsys.fs["1/status"].Data = append(fsys.fs["1/status"].Data, "NSpid:\t1\n"...)
+	for i, pid := range []int{300, 301} {
+		dir := fmt.Sprint(pid)
+		fsys.fs[dir+"/stat"] = &fstest.MapFile{Data: testStat(pid, "sh", 'S', 299+i, 0, 0, 100)}
+		fsys.fs[dir+"/status"] = &fstest.MapFile{Data: append(testStatus("sh", "S (sleeping)", pid, 299+i, 0),
+			fmt.Sprintf("NSpid:\t%d\t%d\n", pid, i+1)...)}
+		fsys.fs[dir+"/ns/pid"] = testLink(fmt.Sprintf("pid:[%d]", testContainerPidNS))
+		fsys.fs[dir+"/ns/net"] = testLink("net:[4026532500]")
+	}
+	return fsys
+}
+
+func TestNamespaces(t *testing.T) {
+	src := NewSourceFS(testNSFS())
+	p, err := src.ProcessByPid(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ns, err := p.Namespaces()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := Namespaces{
+		Cgroup: 4026531840,
+		IPC:    4026531840,
+		Mnt:    4026531840,
+		Net:    4026531840,
+		PID:    testHostPidNS,
+		User:   4026531840,
+		UTS:    4026531840,
+	}
+	if *ns != want {
+		t.Errorf("Got namespaces %+v, want %+v", *ns, want)
+	}
+
+	p, err = src.ProcessByPid(301)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ns, err = p.Namespaces()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ns.PID != testContainerPidNS || ns.Net != 4026532500 || ns.Mnt != 0 {
+		t.Errorf("Got namespaces %+v", *ns)
+	}
+
+	p, err = src.ProcessByPid(2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := p.Namespaces(); err != syscall.ESRCH {
+		t.Errorf("Got error %v, want %v", err, syscall.ESRCH)
+	}
+}
+
+func TestParseNSLink(t *testing.T) {
+	for _, tt := range []struct {
+		name, target string
+		inode        uint64
+		ok           bool
+	}{
+		{"pid", "pid:[4026531836]", 4026531836, true},
+		{"net", "net:[1]", 1, true},
+		{"pid", "net:[4026531836]", 0, false},
+		{"pid", "pid:[4026531836", 0, false},
+		{"pid", "pid:[x]", 0, false},
+	} {
+		inode, err := parseNSLink(tt.name, tt.target)
+		if (err == nil) != tt.ok || inode != tt.inode {
+			t.Errorf("parseNSLink(%q, %q) got %d, %v", tt.name, tt.target, inode, err)
+		}
+	}
+}
+
+func TestNSPids(t *testing.T) {
+	src := NewSourceFS(testNSFS())
+	for _, tt := range []struct {
+		pid   int
+		pids  string
+		nspid int
+	}{
+		{1, "[1]", 1},
+		{300, "[300 1]", 1},
+		{301, "[301 2]", 2},
+	} {
+		p, err := src.ProcessByPid(tt.pid)
+		if err != nil {
+			t.Fatal(err)
+		}
+		pids, err := p.NSPids()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if fmt.Sprint(pids) != tt.pids {
+			t.Errorf("%d: got NSpid %v, want %s", tt.pid, pids, tt.pids)
+		}
+		nspid, err := p.NamespacePid()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if nspid != tt.nspid {
+			t.Errorf("%d: got namespace pid %d, want %d", tt.pid, nspid, tt.nspid)
+		}
+	}
+	p, err := src.ProcessByPid(2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := p.NSPids(); !IsUnset(err) {
+		t.Errorf("Got error %v, want unset", err)
+	}
+}
+
+func TestHostPid(t *testing.T) {
+	src := NewSourceFS(testNSFS())
+	for _, tt := range []struct {
+		ns   uint64
+		pid  int
+		host int
+	}{
+		{testContainerPidNS, 1, 300},
+		{testContainerPidNS, 2, 301},
+		{testHostPidNS, 1, 1},
+		{testContainerPidNS, 3, 0},
+		{42, 1, 0},
+	} {
+		host, err := src.HostPid(tt.ns, tt.pid)
+		if tt.host == 0 {
+			if err != syscall.ESRCH {
+				t.Errorf("HostPid(%d, %d) got %d, %v, want ESRCH", tt.ns, tt.pid, host, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		if host != tt.host {
+			t.Errorf("HostPid(%d, %d) got %d, want %d", tt.ns, tt.pid, host, tt.host)
+		}
+	}
+}
+
+func TestNamespacesLive(t *testing.T) {
+	p := &Process{ID: mypid}
+	ns, err := p.Namespaces()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ns.PID == 0 || ns.Net == 0 || ns.Mnt == 0 {
+		t.Fatalf("Got namespaces %+v", *ns)
+	}
+	pids, err := p.NSPids()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pids[0] != mypid {
+		t.Errorf("Got NSpid %v, want it to start with %d", pids, mypid)
+	}
+	nspid, err := p.NamespacePid()
+	if err != nil {
+		t.Fatal(err)
+	}
+	host, err := HostPid(ns.PID, nspid)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if host != mypid {
+		t.Errorf("Got host pid %d, want %d", host, mypid)
+	}
+}
@@ -0,0 +1,54 @@
+//go:build linux
+
+package ps
+
+import (
+	"syscall"
+)
+
+// signal sends sig to p if p is still the process it refers to.  A pidfd for
+// the ID of p is opened before p is checked, and the signal is sent through
+// it, so the signal cannot be sent to a process that reused the ID after the
+// check.  If pidfds are not supported, such as before linux 5.3, the signal
+// is sent by ID.
+func (p *Process) signal(sig syscall.Signal) error {
+	if p.source().fsys != nil {
+		return ErrNotLive
+	}
+	fd, err := pidfdOpen(p.ID)
+	switch err {
+	case nil:
+		defer syscall.Close(fd)
+	case syscall.ESRCH:
+		return err
+	default:
+		// Either pidfds are not supported or p is a thread that is not
+		// a thread group leader (EINVAL).
+		if _, err := p.current(); err != nil {
+			return err
+		}
+		return syscall.Kill(p.ID, sig)
+	}
+	if _, err := p.current(); err != nil {
+		return err
+	}
+	return pidfdSendSignal(fd, sig)
+}
+
+// pidfdOpen returns a pidfd referring to the process pid.
+func pidfdOpen(pid int) (int, error) {
+	fd, _, errno := syscall.Syscall(sysPidfdOpen, uintptr(pid), 0, 0)
+	if errno != 0 {
+		return -1, errno
+	}
+	return int(fd), nil
+}
+
+// pidfdSendSignal sends sig to the process referred to by the pidfd fd.
+func pidfdSendSignal(fd int, sig syscall.Signal) error {
+	_, _, errno := syscall.Syscall6(sysPidfdSendSignal, uintptr(fd), uintptr(sig), 0, 0, 0, 0)
+	if errno != 0 {
+		return errno
+	}
+	return nil
+}
@@ -0,0 +1,10 @@
+//go:build linux && !mips && !mipsle && !mips64 && !mips64le
+
+package ps
+
+// System call numbers of pidfd_open and pidfd_send_signal, which are the same
+// on every architecture but mips.
+const (
+	sysPidfdOpen       = 434
+	sysPidfdSendSignal = 424
+)
@@ -0,0 +1,9 @@
+//go:build linux && (mips64 || mips64le)
+
+package ps
+
+// System call numbers of pidfd_open and pidfd_send_signal in the n64 ABI.
+const (
+	sysPidfdOpen       = 5434
+	sysPidfdSendSignal = 5424
+)
@@ -0,0 +1,9 @@
+//go:build linux && (mips || mipsle)
+
+package ps
+
+// System call numbers of pidfd_open and pidfd_send_signal in the o32 ABI.
+const (
+	sysPidfdOpen       = 4434
+	sysPidfdSendSignal = 4424
+)
@@ -0,0 +1,203 @@
+//go:build linux
+
+package ps
+
+import (
+	"fmt"
+	"math/bits"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+const (
+	SIGRTMIN = syscall.Signal(32) // The first real-time signal
+	SIGRTMAX = syscall.Signal(64) // The last real-time signal in a SignalSet
+)
+
+// signalNames are the names of the standard signals.
+var signalNames = map[syscall.Signal]string{
+	syscall.SIGHUP:    "SIGHUP",
+	syscall.SIGINT:    "SIGINT",
+	syscall.SIGQUIT:   "SIGQUIT",
+	syscall.SIGILL:    "SIGILL",
+	syscall.SIGTRAP:   "SIGTRAP",
+	syscall.SIGABRT:   "SIGABRT",
+	syscall.SIGBUS:    "SIGBUS",
+	syscall.SIGFPE:    "SIGFPE",
+	syscall.SIGKILL:   "SIGKILL",
+	syscall.SIGUSR1:   "SIGUSR1",
+	syscall.SIGSEGV:   "SIGSEGV",
+	syscall.SIGUSR2:   "SIGUSR2",
+	syscall.SIGPIPE:   "SIGPIPE",
+	syscall.SIGALRM:   "SIGALRM",
+	syscall.SIGTERM:   "SIGTERM",
+	syscall.SIGCHLD:   "SIGCHLD",
+	syscall.SIGCONT:   "SIGCONT",
+	syscall.SIGSTOP:   "SIGSTOP",
+	syscall.SIGTSTP:   "SIGTSTP",
+	syscall.SIGTTIN:   "SIGTTIN",
+	syscall.SIGTTOU:   "SIGTTOU",
+	syscall.SIGURG:    "SIGURG",
+	syscall.SIGXCPU:   "SIGXCPU",
+	syscall.SIGXFSZ:   "SIGXFSZ",
+	syscall.SIGVTALRM: "SIGVTALRM",
+	syscall.SIGPROF:   "SIGPROF",
+	syscall.SIGWINCH:  "SIGWINCH",
+	syscall.SIGIO:     "SIGIO",
+	syscall.SIGPWR:    "SIGPWR",
+	syscall.SIGSYS:    "SIGSYS",
+}
+
+// SignalName returns the name of sig, e.g., "SIGTERM".  Real-time signals are
+// named relative to SIGRTMIN, e.g., "SIGRTMIN+3", using the kernel's value of
+// SIGRTMIN rather than the C library's, which reserves the first few real-time
+// signals for its own use.
+// SignalName is only available on linux.
+func SignalName(sig syscall.Signal) string {
+	if name, ok := signalNames[sig]; ok {
+		return name
+	}
+	switch {
+	case sig == SIGRTMIN:
+		return "SIGRTMIN"
+	case sig == SIGRTMAX:
+		return "SIGRTMAX"
+	case sig > SIGRTMIN && sig < SIGRTMAX:
+		return fmt.Sprintf("SIGRTMIN+%d", int(sig-SIGRTMIN))
+	}
+	return fmt.Sprintf("signal %d", int(sig))
+}
+
+// A SignalSet is a set of signals, as found in the Sig* lines of
+// /proc/PID/status and the signal fields of Stat.  Bit N-1 of a SignalSet is
+// set if signal N is in the set.  The signal masks of Stat can be converted
+// directly, e.g., SignalSet(stat.Sigcatch), but the kernel only reports the
+// first 31 signals in them.  On mips, which has 127 signals, a SignalSet only
+// holds the first 64.
+// SignalSet is only available on linux.
+type SignalSet uint64
+
+// NewSignalSet returns a SignalSet containing sigs.
+func NewSignalSet(sigs ...syscall.Signal) SignalSet {
+	var s SignalSet
+	for _, sig := range sigs {
+		s = s.Add(sig)
+	}
+	return s
+}
+
+// Has returns true if sig is in s.
+func (s SignalSet) Has(sig syscall.Signal) bool {
+	return sig >= 1 && sig <= 64 && s&(1<<uint(sig-1)) != 0
+}
+
+// Add returns s with sig added.
+func (s SignalSet) Add(sig syscall.Signal) SignalSet {
+	if sig < 1 || sig > 64 {
+		return s
+	}
+	return s | 1<<uint(sig-1)
+}
+
+// Remove returns s with sig removed.
+func (s SignalSet) Remove(sig syscall.Signal) SignalSet {
+	if sig < 1 || sig > 64 {
+		return s
+	}
+	return s &^ (1 << uint(sig-1))
+}
+
+// Union returns the signals in either s or o.
+func (s SignalSet) Union(o SignalSet) SignalSet {
+	return s | o
+}
+
+// Intersect returns the signals in both s and o.
+func (s SignalSet) Intersect(o SignalSet) SignalSet {
+	return s & o
+}
+
+// Difference returns the signals in s that are not in o.
+func (s SignalSet) Difference(o SignalSet) SignalSet {
+	return s &^ o
+}
+
+// Signals returns the signals in s in numeric order.
+func (s SignalSet) Signals() []syscall.Signal {
+	sigs := make([]syscall.Signal, 0, bits.OnesCount64(uint64(s)))
+	for sig := syscall.Signal(1); sig <= 64; sig++ {
+		if s.Has(sig) {
+			sigs = append(sigs, sig)
+		}
+	}
+	return sigs
+}
+
+// String returns the names of the signals in s separated by commas, e.g.,
+// "SIGINT,SIGTERM".  The empty set is returned as "".
+func (s SignalSet) String() string {
+	var names []string
+	for _, sig := range s.Signals() {
+		names = append(names, SignalName(sig))
+	}
+	return strings.Join(names, ",")
+}
+
+// Signals are the signal sets of a process.
+// Signals is only available on linux.
+type Signals struct {
+	Pending       SignalSet // SigPnd: pending for the thread
+	SharedPending SignalSet // ShdPnd: pending for the process as a whole
+	Blocked       SignalSet // SigBlk: blocked by the thread
+	Ignored       SignalSet // SigIgn: ignored
+	Caught        SignalSet // SigCgt: have a handler installed
+}
+
+// Signals returns the signal sets of p from /proc/PID/status.  The pending
+// and blocked sets are those of the thread whose ID is p's ID, normally the
+// main thread of the process.
+// Signals is only available on linux.
+func (p *Process) Signals() (*Signals, error) {
+	status, err := p.StatusMap(true)
+	if err != nil {
+		return nil, err
+	}
+	sigs := &Signals{}
+	for _, s := range []struct {
+		name string
+		set  *SignalSet
+	}{
+		{"SigPnd", &sigs.Pending},
+		{"ShdPnd", &sigs.SharedPending},
+		{"SigBlk", &sigs.Blocked},
+		{"SigIgn", &sigs.Ignored},
+		{"SigCgt", &sigs.Caught},
+	} {
+		v, ok := status[s.name]
+		if !ok {
+			return nil, ErrUnset(s.name)
+		}
+		set, err := parseSignalSet(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s: %v", s.name, err)
+		}
+		*s.set = set
+	}
+	return sigs, nil
+}
+
+// parseSignalSet returns the signal mask v, a Sig* line of /proc/PID/status,
+// as a SignalSet.  Masks wider than 64 bits, such as the 128 bit masks of
+// mips, are truncated to their first 64 signals.
+func parseSignalSet(v StatusValue) (SignalSet, error) {
+	s := string(v)
+	if len(s) > 16 {
+		if _, err := strconv.ParseUint(s[:len(s)-16], 16, 64); err != nil {
+			return 0, err
+		}
+		s = s[len(s)-16:]
+	}
+	set, err := strconv.ParseUint(s, 16, 64)
+	return SignalSet(set), err
+}
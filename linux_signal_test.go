@@ -0,0 +1,161 @@
+//go:build linux
+
+package ps
+
+import (
+	"fmt"
+	"os/signal"
+	"syscall"
+	"testing"
+)
+
+func TestSignalName(t *testing.T) {
+	for _, tt := range []struct {
+		sig  syscall.Signal
+		name string
+	}{
+		{syscall.SIGHUP, "SIGHUP"},
+		{syscall.SIGTERM, "SIGTERM"},
+		{syscall.SIGSYS, "SIGSYS"},
+		{SIGRTMIN, "SIGRTMIN"},
+		{SIGRTMIN + 2, "SIGRTMIN+2"},
+		{SIGRTMAX - 1, "SIGRTMIN+31"},
+		{SIGRTMAX, "SIGRTMAX"},
+		{0, "signal 0"},
+		{65, "signal 65"},
+	} {
+		if name := SignalName(tt.sig); name != tt.name {
+			t.Errorf("SignalName(%d) got %q, want %q", int(tt.sig), name, tt.name)
+		}
+	}
+}
+
+func TestSignalSet(t *testing.T) {
+	s := NewSignalSet(syscall.SIGTERM, syscall.SIGHUP, SIGRTMIN+2, SIGRTMAX)
+	if s != 1<<0|1<<14|1<<33|1<<63 {
+		t.Errorf("Got set %#x", uint64(s))
+	}
+	if !s.Has(syscall.SIGTERM) || s.Has(syscall.SIGINT) || s.Has(0) || s.Has(65) {
+		t.Errorf("Has is wrong for %#x", uint64(s))
+	}
+	if got, want := s.String(), "SIGHUP,SIGTERM,SIGRTMIN+2,SIGRTMAX"; got != want {
+		t.Errorf("Got %q, want %q", got, want)
+	}
+	if got := fmt.Sprint(s.Signals()); got != fmt.Sprint([]syscall.Signal{1, 15, 34, 64}) {
+		t.Errorf("Got signals %v", got)
+	}
+	if s := SignalSet(0).String(); s != "" {
+		t.Errorf("Got %q for the empty set", s)
+	}
+
+	o := NewSignalSet(syscall.SIGTERM, syscall.SIGINT)
+	for _, tt := range []struct {
+		name      string
+		got, want SignalSet
+	}{
+		{"Add", o.Add(syscall.SIGQUIT), NewSignalSet(syscall.SIGTERM, syscall.SIGINT, syscall.SIGQUIT)},
+		{"Add invalid", o.Add(0), o},
+		{"Remove", o.Remove(syscall.SIGINT), NewSignalSet(syscall.SIGTERM)},
+		{"Union", s.Union(o), s.Add(syscall.SIGINT)},
+		{"Intersect", s.Intersect(o), NewSignalSet(syscall.SIGTERM)},
+		{"Difference", s.Difference(o), NewSignalSet(syscall.SIGHUP, SIGRTMIN+2, SIGRTMAX)},
+	} {
+		if tt.got != tt.want {
+			t.Errorf("%s: got %v, want %v", tt.name, tt.got, tt.want)
+		}
+	}
+}
+
+func TestSignals(t *testing.T) {
+	fsys := testProcFS()
+	fsys.fs["1/status"].Data = append(fsys.fs["1/status"].Data,
+		"SigQ:\t0/63704\nSigPnd:\t0000000000000000\nShdPnd:\t0000000000000100\nSigBlk:\t7be3c0fe28014a03\nSigIgn:\t0000000000001000\nSigCgt:\t00000001000004ec\n"...)
+	fsys.fs["3/status"].Data = append(fsys.fs["3/status"].Data,
+		"SigPnd:\t0000000000000000\nShdPnd:\t0000000000000000\nSigBlk:\tffffffffffffffff\nSigIgn:\tffffffffffffffff\nSigCgt:\t0000000000000000\n"...)
+	// mips has 128 bit masks.
+	fsys.fs["100/status"].Data = append(fsys.fs["100/status"].Data,
+		"SigPnd:\t00000000000000000000000000000000\nShdPnd:\t00000000000000000000000000000100\nSigBlk:\tffffffff00000000fffffffffffffffe\nSigIgn:\t00000000000000000000000000001000\nSigCgt:\t000000000000000100000000000004ec\n"...)
+	src := NewSourceFS(fsys)
+
+	p, err := src.ProcessByPid(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sigs, err := p.Signals()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := Signals{
+		SharedPending: NewSignalSet(syscall.SIGKILL),
+		Blocked:       0x7be3c0fe28014a03,
+		Ignored:       NewSignalSet(syscall.SIGPIPE),
+		Caught:        NewSignalSet(syscall.SIGQUIT, syscall.SIGILL, syscall.SIGABRT, syscall.SIGBUS, syscall.SIGFPE, syscall.SIGSEGV, SIGRTMIN+1),
+	}
+	if *sigs != want {
+		t.Errorf("Got %+v, want %+v", *sigs, want)
+	}
+
+	p, err = src.ProcessByPid(3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sigs, err = p.Signals()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sigs.Ignored.Signals()) != 64 || sigs.Caught != 0 {
+		t.Errorf("Got %+v", *sigs)
+	}
+
+	p, err = src.ProcessByPid(100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sigs, err = p.Signals()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want = Signals{
+		SharedPending: NewSignalSet(syscall.SIGKILL),
+		Blocked:       0xfffffffffffffffe,
+		Ignored:       NewSignalSet(syscall.SIGPIPE),
+		Caught:        0x4ec,
+	}
+	if *sigs != want {
+		t.Errorf("Got 128 bit masks %+v, want %+v", *sigs, want)
+	}
+
+	p, err = src.ProcessByPid(2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := p.Signals(); !IsUnset(err) {
+		t.Errorf("Got error %v, want unset", err)
+	}
+}
+
+func TestSignalsLive(t *testing.T) {
+	signal.Ignore(syscall.SIGUSR2)
+	defer signal.Reset(syscall.SIGUSR2)
+
+	p := &Process{ID: mypid}
+	sigs, err := p.Signals()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !sigs.Ignored.Has(syscall.SIGUSR2) {
+		t.Errorf("SIGUSR2 is not ignored: %v", sigs.Ignored)
+	}
+	// The Go runtime catches SIGTERM.
+	if !sigs.Caught.Has(syscall.SIGTERM) {
+		t.Errorf("SIGTERM is not caught: %v", sigs.Caught)
+	}
+	st, err := p.Stat(true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The kernel only reports the first 31 signals in stat.
+	if caught := sigs.Caught & (1<<31 - 1); SignalSet(st.Sigcatch) != caught {
+		t.Errorf("Stat caught %v, status caught %v", SignalSet(st.Sigcatch), caught)
+	}
+}
@@ -0,0 +1,10 @@
+//go:build linux && !mips && !mipsle && !mips64 && !mips64le
+
+package ps
+
+import "syscall"
+
+// SIGSTKFLT is not defined on mips.
+func init() {
+	signalNames[syscall.SIGSTKFLT] = "SIGSTKFLT"
+}
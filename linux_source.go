@@ -0,0 +1,234 @@
+//go:build linux
+
+package ps
+
+import (
+	"bytes"
+	"errors"
+	"io/fs"
+	"io/ioutil"
+	"os"
+	"path"
+	"strconv"
+	"syscall"
+)
+
+// A Source is a procfs file system that process information is read from.
+// The package level functions, such as Processes and ProcessByPid, read from
+// the default source, /proc.  A Source can be used to read from a procfs
+// mounted elsewhere, such as the host's /proc bind mounted into a container.
+//
+// Every Process returned by a Source reads its information from that Source.
+// Source is only available on linux.
+type Source struct {
+	root string
+	fsys fs.FS
+}
+
+var defaultSource = NewSource("/proc")
+
+// NewSource returns a Source that reads from the procfs mounted at root.
+func NewSource(root string) *Source {
+	return &Source{root: path.Clean(root)}
+}
+
+// NewSourceFS returns a Source that reads from fsys, which must be laid out as
+// the root of a procfs (e.g., fsys contains the file "1/stat").  It is
+// normally used to read a synthetic or captured tree, such as an
+// fstest.MapFS.
+//
+// Links, such as PID/exe, are read with a ReadLink method if fsys has one (as
+// do os.DirFS and fstest.MapFS in newer releases of Go).  Otherwise, or if the
+// file is not a symbolic link, the contents of the file are used as the
+// target of the link.
+//
+// The uid and gid of a process are taken from the Sys value of its directory's
+// FileInfo if it is a *syscall.Stat_t.  Otherwise they are taken from the
+// effective ids in PID/status.
+func NewSourceFS(fsys fs.FS) *Source {
+	return &Source{root: ".", fsys: fsys}
+}
+
+// Root returns the root directory of s.  Root returns "." for sources created
+// by NewSourceFS.
+func (s *Source) Root() string {
+	return s.root
+}
+
+// Processes returns a list of all processes found in s.  Setting filled to
+// true will also stat the directory of each process, filling in its uid and
+// gid.  Processes that exit while being filled are not returned.  Use Tasks to
+// also include the threads of each process.
+func (s *Source) Processes(filled bool) ([]*Process, error) {
+	pids, err := s.listallpids()
+	if err != nil {
+		return nil, err
+	}
+	p := make([]*Process, 0, len(pids))
+	for _, pid := range pids {
+		pr := &Process{
+			ID:  pid,
+			src: s,
+			dir: s.dirname(pid),
+		}
+		if filled {
+			if err := pr.getStat(); err != nil {
+				continue
+			}
+		}
+		p = append(p, pr)
+	}
+	return p, nil
+}
+
+// ProcessByPid returns the Process in s associated with pid.  The stat of the
+// process is read and cached, recording the start time that identifies it to
+// Signal.
+func (s *Source) ProcessByPid(pid int) (*Process, error) {
+	p := &Process{
+		ID:  pid,
+		src: s,
+	}
+	if err := p.getStat(); err != nil {
+		return nil, err
+	}
+	p.Stat()
+	return p, nil
+}
+
+// ProcessByName returns the list of processes in s with the provided name.  See
+// the package level ProcessByName for how name is matched.
+func (s *Source) ProcessByName(name string) ([]*Process, error) {
+	if name == "" {
+		return nil, nil
+	}
+	ps, err := s.Processes(false)
+	if err != nil {
+		return nil, err
+	}
+	return matchName(ps, name), nil
+}
+
+// GetProcessMap returns a process map of all processes in s.  It returns nil
+// if the processes cannot be read.
+func (s *Source) GetProcessMap() *ProcessMap {
+	procs, err := s.Processes(true)
+	if err != nil {
+		return nil
+	}
+	return newProcessMap(procs)
+}
+
+// dirname returns the directory in s that holds the information for pid.
+func (s *Source) dirname(pid int) string {
+	if s.fsys != nil {
+		return strconv.Itoa(pid)
+	}
+	return s.root + "/" + strconv.Itoa(pid)
+}
+
+func (s *Source) readFile(name string) ([]byte, error) {
+	if s.fsys != nil {
+		return fs.ReadFile(s.fsys, name)
+	}
+	return ioutil.ReadFile(name)
+}
+
+// A readLinkFS is a file system that can read symbolic links.  It has the
+// same method as fs.ReadLinkFS, which is not available in all releases of Go.
+type readLinkFS interface {
+	ReadLink(name string) (string, error)
+}
+
+func (s *Source) readlink(name string) (string, error) {
+	if s.fsys == nil {
+		return os.Readlink(name)
+	}
+	if rfs, ok := s.fsys.(readLinkFS); ok {
+		target, err := rfs.ReadLink(name)
+		if !errors.Is(err, fs.ErrInvalid) {
+			return target, err
+		}
+		// name is not a link, fall back to its contents.
+	}
+	data, err := fs.ReadFile(s.fsys, name)
+	if err != nil {
+		return "", err
+	}
+	return string(bytes.TrimSuffix(data, []byte{'\n'})), nil
+}
+
+func (s *Source) stat(name string) (*syscall.Stat_t, error) {
+	if s.fsys != nil {
+		return s.statFS(name)
+	}
+	var stat syscall.Stat_t
+	if err := syscall.Stat(name, &stat); err != nil {
+		return nil, err
+	}
+	return &stat, nil
+}
+
+// statFS returns the stat of the process directory name in s.fsys.
+func (s *Source) statFS(name string) (*syscall.Stat_t, error) {
+	fi, err := fs.Stat(s.fsys, name)
+	if err != nil {
+		return nil, err
+	}
+	if stat, ok := fi.Sys().(*syscall.Stat_t); ok {
+		return stat, nil
+	}
+	stat := &syscall.Stat_t{
+		Mode: uint32(fi.Mode().Perm()),
+	}
+	if fi.IsDir() {
+		stat.Mode |= syscall.S_IFDIR
+	}
+	p := &Process{src: s, dir: name}
+	if v, err := p.StatusValue("Uid"); err == nil {
+		if creds, err := v.AsCreds(); err == nil {
+			stat.Uid = uint32(creds.Effective)
+		}
+	}
+	if v, err := p.StatusValue("Gid"); err == nil {
+		if creds, err := v.AsCreds(); err == nil {
+			stat.Gid = uint32(creds.Effective)
+		}
+	}
+	return stat, nil
+}
+
+func (s *Source) readDirNames(name string) ([]string, error) {
+	if s.fsys != nil {
+		des, err := fs.ReadDir(s.fsys, name)
+		if err != nil {
+			return nil, err
+		}
+		names := make([]string, len(des))
+		for i, de := range des {
+			names[i] = de.Name()
+		}
+		return names, nil
+	}
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	names, err := f.Readdirnames(-1)
+	f.Close()
+	return names, err
+}
+
+func (s *Source) listallpids() ([]int, error) {
+	names, err := s.readDirNames(s.root)
+	if err != nil {
+		return nil, err
+	}
+	var pids []int
+	for _, name := range names {
+		if pid, err := strconv.Atoi(name); err == nil {
+			pids = append(pids, pid)
+		}
+	}
+	return pids, nil
+}
@@ -0,0 +1,347 @@
+//go:build linux
+
+package ps
+
+import (
+	"fmt"
+	"io/fs"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"syscall"
+	"testing"
+	"testing/fstest"
+)
+
+// mkProcDir creates a minimal procfs tree in a temporary directory.  Each
+// process has a stat file and an exe link.
+func mkProcDir(t *testing.T) string {
+	root, err := ioutil.TempDir("", "ps-proc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(root) })
+	for _, p := range []struct {
+		pid  string
+		stat string
+		exe  string
+	}{
+		{"1", "1 (init) S 0 1 1 0 -1 4194560 0 0 0 0 0 0 0 0 20 0 1 0 10 0\n", "/sbin/init"},
+		{"42", "42 (sshd) S 1 42 42 0 -1 4194560 0 0 0 0 0 0 0 0 20 0 1 0 20 0\n", "/usr/sbin/sshd"},
+		{"43", "43 (bash) S 42 43 43 0 -1 4194560 0 0 0 0 0 0 0 0 20 0 1 0 30 0\n", "/bin/bash"},
+	} {
+		dir := filepath.Join(root, p.pid)
+		if err := os.Mkdir(dir, 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(filepath.Join(dir, "stat"), []byte(p.stat), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Symlink(p.exe, filepath.Join(dir, "exe")); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.Mkdir(filepath.Join(root, "sys"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	return root
+}
+
+func TestSourceProcesses(t *testing.T) {
+	src := NewSource(mkProcDir(t))
+	procs, err := src.Processes(true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var pids []int
+	for _, p := range procs {
+		if p.src != src {
+			t.Errorf("Process[%d] not from source", p.ID)
+		}
+		if p.sysstat == nil {
+			t.Errorf("Process[%d] does not have sysstat filled", p.ID)
+		}
+		pids = append(pids, p.ID)
+	}
+	sort.Ints(pids)
+	if len(pids) != 3 || pids[0] != 1 || pids[1] != 42 || pids[2] != 43 {
+		t.Errorf("Got pids %v, want [1 42 43]", pids)
+	}
+
+	p, err := src.ProcessByPid(43)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ppid, err := p.Ppid()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ppid != 42 {
+		t.Errorf("Got ppid %d, want 42", ppid)
+	}
+	if _, err := src.ProcessByPid(44); err == nil {
+		t.Errorf("ProcessByPid(44) did not fail")
+	}
+}
+
+func TestSourceProcessByName(t *testing.T) {
+	src := NewSource(mkProcDir(t))
+	for _, tt := range []struct {
+		name string
+		pid  int
+	}{
+		{"sshd", 42},
+		{"/bin/bash", 43},
+		{"sbin/init", 1},
+		{"sbin/sshd", 42},
+		{"bin/init", 0},
+		{"nothing", 0},
+	} {
+		procs, err := src.ProcessByName(tt.name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		switch {
+		case tt.pid == 0 && len(procs) != 0:
+			t.Errorf("%s: got %d processes, want none", tt.name, len(procs))
+		case tt.pid == 0:
+		case len(procs) != 1:
+			t.Errorf("%s: got %d processes, want 1", tt.name, len(procs))
+		case procs[0].ID != tt.pid:
+			t.Errorf("%s: got pid %d, want %d", tt.name, procs[0].ID, tt.pid)
+		}
+	}
+}
+
+func TestSourceGetProcessMap(t *testing.T) {
+	pm := NewSource(mkProcDir(t)).GetProcessMap()
+	if pm == nil {
+		t.Fatal("GetProcessMap returned nil")
+	}
+	if got := pm.GetDecendents(1); len(got) != 2 || got[0] != 42 || got[1] != 43 {
+		t.Errorf("Got decendents %v, want [42 43]", got)
+	}
+	if len(pm.Pids[42].Children) != 1 || pm.Pids[42].Children[0].ID != 43 {
+		t.Errorf("Process 42 has wrong children")
+	}
+	if NewSource("/does/not/exist").GetProcessMap() != nil {
+		t.Errorf("GetProcessMap of missing root is not nil")
+	}
+}
+
+// A denyFS is an fs.FS that returns a permission error when opening any of
+// the names in deny.
+type denyFS struct {
+	fs   fstest.MapFS
+	deny map[string]bool
+}
+
+func (d denyFS) Open(name string) (fs.File, error) {
+	if d.deny[name] {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrPermission}
+	}
+	return d.fs.Open(name)
+}
+
+// ReadLink returns the target of the link name, which is the data of a file
+// whose mode includes fs.ModeSymlink.  fstest.MapFS only has a ReadLink method
+// in newer releases of Go.
+func (d denyFS) ReadLink(name string) (string, error) {
+	if d.deny[name] {
+		return "", &fs.PathError{Op: "readlink", Path: name, Err: fs.ErrPermission}
+	}
+	f := d.fs[name]
+	if f == nil {
+		return "", &fs.PathError{Op: "readlink", Path: name, Err: fs.ErrNotExist}
+	}
+	if f.Mode&fs.ModeSymlink == 0 {
+		return "", &fs.PathError{Op: "readlink", Path: name, Err: fs.ErrInvalid}
+	}
+	return string(f.Data), nil
+}
+
+// testProcStat is the stat file of a system with 2 CPUs.
+const testProcStat = "cpu  100 0 100 1000 0 0 0 0 0 0\ncpu0 50 0 50 500 0 0 0 0 0 0\ncpu1 50 0 50 500 0 0 0 0 0 0\nbtime 1600000000\n"
+
+// testStat returns the contents of a stat file for pid.
+func testStat(pid int, comm string, state byte, ppid int, utime, stime, starttime uint64) []byte {
+	return []byte(fmt.Sprintf("%d (%s) %c %d %d %d 0 -1 4194560 0 0 0 0 %d %d 0 0 20 0 1 0 %d 0 0\n",
+		pid, comm, state, ppid, pid, pid, utime, stime, starttime))
+}
+
+// testStatus returns the contents of a status file.
+func testStatus(name string, state string, pid, ppid, uid int) []byte {
+	return []byte(fmt.Sprintf("Name:\t%s\nUmask:\t0022\nState:\t%s\nTgid:\t%d\nPid:\t%d\nPPid:\t%d\nUid:\t%d\t%d\t%d\t%d\nGid:\t%d\t%d\t%d\t%d\nGroups:\t%d\n",
+		name, state, pid, pid, ppid, uid, uid, uid, uid, uid, uid, uid, uid, uid))
+}
+
+func testLink(target string) *fstest.MapFile {
+	return &fstest.MapFile{Data: []byte(target), Mode: fs.ModeSymlink | 0777}
+}
+
+// testProcFS returns a synthetic procfs containing init, a kernel thread and
+// its worker, a process with a truncated command name whose exe link cannot be
+// read, and a zombie.
+func testProcFS() denyFS {
+	return denyFS{
+		fs: fstest.MapFS{
+			"1/stat":          {Data: testStat(1, "systemd", 'S', 0, 100, 50, 1)},
+			"1/status":        {Data: testStatus("systemd", "S (sleeping)", 1, 0, 0)},
+			"1/cmdline":       {Data: []byte("/sbin/init\000splash\000")},
+			"1/environ":       {Data: []byte("HOME=/\000TERM=linux\000")},
+			"1/exe":           testLink("/usr/lib/systemd/systemd"),
+			"1/fd/0":          testLink("/dev/null"),
+			"1/fd/3":          testLink("socket:[12345]"),
+			"1/task/1/stat":   {Data: testStat(1, "systemd", 'S', 0, 40, 20, 1)},
+			"1/task/1/status": {Data: testStatus("systemd", "S (sleeping)", 1, 0, 0)},
+			"1/task/7/stat":   {Data: testStat(7, "sd-worker", 'R', 0, 60, 30, 5)},
+			"1/task/7/status": {Data: testStatus("sd-worker", "R (running)", 7, 0, 0)},
+			"2/stat":          {Data: testStat(2, "kthreadd", 'S', 0, 0, 0, 1)},
+			"2/status":        {Data: testStatus("kthreadd", "S (sleeping)", 2, 0, 0)},
+			"2/cmdline":       {},
+			"3/stat":          {Data: testStat(3, "kworker/0:1-events", 'I', 2, 0, 7, 2)},
+			"3/status":        {Data: testStatus("kworker/0:1-events", "I (idle)", 3, 2, 0)},
+			"3/cmdline":       {},
+			"100/stat":        {Data: testStat(100, "systemd-timesyn", 'S', 1, 10, 5, 300)},
+			"100/status":      {Data: testStatus("systemd-timesyn", "S (sleeping)", 100, 1, 102)},
+			"100/cmdline":     {Data: []byte("/lib/systemd/systemd-timesyncd\000")},
+			"100/exe":         testLink("/lib/systemd/systemd-timesyncd"),
+			"200/stat":        {Data: testStat(200, "defunct", 'Z', 100, 1, 1, 400)},
+			"200/status":      {Data: testStatus("defunct", "Z (zombie)", 200, 100, 102)},
+			"200/cmdline":     {},
+			"self":            testLink("200"),
+			"stat":            {Data: []byte(testProcStat)},
+			"uptime":          {Data: []byte("1000.00 2000.00\n")},
+		},
+		deny: map[string]bool{
+			"100/exe":     true,
+			"100/environ": true,
+		},
+	}
+}
+
+func TestSourceFS(t *testing.T) {
+	src := NewSourceFS(testProcFS())
+	procs, err := src.Processes(true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(procs) != 5 {
+		t.Fatalf("Got %d processes, want 5", len(procs))
+	}
+
+	p, err := src.ProcessByPid(100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if uid, err := p.Uid(); err != nil || uid != 102 {
+		t.Errorf("Got uid %d, %v, want 102", uid, err)
+	}
+	if _, err := p.Path(); err != syscall.EPERM {
+		t.Errorf("Path got %v, want %v", err, syscall.EPERM)
+	}
+	if _, err := p.Environ(); err != syscall.EPERM {
+		t.Errorf("Environ got %v, want %v", err, syscall.EPERM)
+	}
+	if cmd, err := p.Command(); err != nil || cmd != "systemd-timesyn" {
+		t.Errorf("Got command %q, %v, want %q", cmd, err, "systemd-timesyn")
+	}
+	argv, err := p.Argv()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(argv) != 1 || argv[0] != "/lib/systemd/systemd-timesyncd" {
+		t.Errorf("Got argv %q", argv)
+	}
+
+	p, err = src.ProcessByPid(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if path, err := p.Path(); err != nil || path != "/usr/lib/systemd/systemd" {
+		t.Errorf("Got path %q, %v", path, err)
+	}
+	if v, err := p.Value("TERM"); err != nil || v != "linux" {
+		t.Errorf("Got TERM %q, %v, want linux", v, err)
+	}
+	if groups, err := p.Groups(); err != nil || len(groups) != 1 || groups[0] != 0 {
+		t.Errorf("Got groups %v, %v, want [0]", groups, err)
+	}
+
+	p, err = src.ProcessByPid(200)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s, err := p.Stat()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.State != 'Z' || s.Ppid != 100 {
+		t.Errorf("Got state %c ppid %d, want Z 100", s.State, s.Ppid)
+	}
+	if argv, err := p.Argv(); err != nil || len(argv) != 0 {
+		t.Errorf("Got zombie argv %q, %v", argv, err)
+	}
+	sm, err := p.StatusMap()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sm["State"] != "Z (zombie)" {
+		t.Errorf("Got status state %q", sm["State"])
+	}
+
+	if _, err := src.ProcessByPid(4); err != syscall.ESRCH && !os.IsNotExist(err) {
+		t.Errorf("ProcessByPid(4) got %v", err)
+	}
+}
+
+func TestSourceFSProcessByName(t *testing.T) {
+	src := NewSourceFS(testProcFS())
+	for _, tt := range []struct {
+		name string
+		pids []int
+	}{
+		{"systemd", []int{1}},
+		{"systemd-timesyncd", []int{100}},
+		{"kworker/0:1-events", nil},
+		{"kthreadd", []int{2}},
+		{"defunct", []int{200}},
+		{"/usr/lib/systemd/systemd", []int{1}},
+	} {
+		procs, err := src.ProcessByName(tt.name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var pids []int
+		for _, p := range procs {
+			pids = append(pids, p.ID)
+		}
+		sort.Ints(pids)
+		if fmt.Sprint(pids) != fmt.Sprint(tt.pids) {
+			t.Errorf("%s: got pids %v, want %v", tt.name, pids, tt.pids)
+		}
+	}
+}
+
+func TestSourceFSGetProcessMap(t *testing.T) {
+	pm := NewSourceFS(testProcFS()).GetProcessMap()
+	if pm == nil {
+		t.Fatal("GetProcessMap returned nil")
+	}
+	for _, tt := range []struct {
+		pid  int
+		want []int
+	}{
+		{1, []int{100, 200}},
+		{2, []int{3}},
+		{100, []int{200}},
+		{200, nil},
+	} {
+		got := pm.GetDecendents(tt.pid)
+		sort.Ints(got)
+		if fmt.Sprint(got) != fmt.Sprint(tt.want) {
+			t.Errorf("GetDecendents(%d) got %v, want %v", tt.pid, got, tt.want)
+		}
+	}
+}
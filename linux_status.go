@@ -0,0 +1,211 @@
+//go:build linux
+
+package ps
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// Status is the decoded contents of /proc/PID/status.  Each field is tagged
+// with the name of the line it is read from, and the line is decoded as
+// described by statusTypes.  Sizes are in bytes.  Fields whose lines are not
+// reported by the kernel, or cannot be decoded, are left as their zero value.
+// Status is only available on linux.
+type Status struct {
+	Name      string `status:"Name"`
+	Umask     int    `status:"Umask"`
+	State     byte   `status:"State"` // E.g., 'R' or 'S'
+	Tgid      int    `status:"Tgid"`
+	Ngid      int    `status:"Ngid"`
+	Pid       int    `status:"Pid"`
+	PPid      int    `status:"PPid"`
+	TracerPid int    `status:"TracerPid"`
+	Uid       Creds  `status:"Uid"`
+	Gid       Creds  `status:"Gid"`
+	FDSize    int    `status:"FDSize"`
+	Groups    []int  `status:"Groups"`
+	NStgid    []int  `status:"NStgid"` // Outermost pid namespace first
+	NSpid     []int  `status:"NSpid"`
+	NSpgid    []int  `status:"NSpgid"`
+	NSsid     []int  `status:"NSsid"`
+
+	VmPeak       int64 `status:"VmPeak"`
+	VmSize       int64 `status:"VmSize"`
+	VmLck        int64 `status:"VmLck"`
+	VmPin        int64 `status:"VmPin"`
+	VmHWM        int64 `status:"VmHWM"`
+	VmRSS        int64 `status:"VmRSS"`
+	RssAnon      int64 `status:"RssAnon"`
+	RssFile      int64 `status:"RssFile"`
+	RssShmem     int64 `status:"RssShmem"`
+	VmData       int64 `status:"VmData"`
+	VmStk        int64 `status:"VmStk"`
+	VmExe        int64 `status:"VmExe"`
+	VmLib        int64 `status:"VmLib"`
+	VmPTE        int64 `status:"VmPTE"`
+	VmSwap       int64 `status:"VmSwap"`
+	HugetlbPages int64 `status:"HugetlbPages"`
+
+	CoreDumping int `status:"CoreDumping"`
+	Threads     int `status:"Threads"`
+
+	SigQ   [2]int64  `status:"SigQ"` // Queued signals and the limit
+	SigPnd SignalSet `status:"SigPnd"`
+	ShdPnd SignalSet `status:"ShdPnd"`
+	SigBlk SignalSet `status:"SigBlk"`
+	SigIgn SignalSet `status:"SigIgn"`
+	SigCgt SignalSet `status:"SigCgt"`
+
+	CapInh CapSet `status:"CapInh"`
+	CapPrm CapSet `status:"CapPrm"`
+	CapEff CapSet `status:"CapEff"`
+	CapBnd CapSet `status:"CapBnd"`
+	CapAmb CapSet `status:"CapAmb"`
+
+	NoNewPrivs             int    `status:"NoNewPrivs"`
+	Seccomp                int    `status:"Seccomp"` // 0 disabled, 1 strict, 2 filter
+	SpeculationStoreBypass string `status:"Speculation_Store_Bypass"`
+
+	CpusAllowed     []int `status:"Cpus_allowed"` // CPUs in the mask
+	CpusAllowedList []int `status:"Cpus_allowed_list"`
+	MemsAllowed     []int `status:"Mems_allowed"` // Memory nodes in the mask
+	MemsAllowedList []int `status:"Mems_allowed_list"`
+
+	VoluntaryCtxtSwitches    int64 `status:"voluntary_ctxt_switches"`
+	NonvoluntaryCtxtSwitches int64 `status:"nonvoluntary_ctxt_switches"`
+
+	// Extras holds the lines not listed in statusTypes, such as those
+	// added by newer kernels, and the lines that could not be decoded.
+	Extras map[string]StatusValue
+}
+
+// statusFields maps the lines of /proc/PID/status to the index of their field
+// in Status.
+var statusFields = func() map[string]int {
+	fields := map[string]int{}
+	t := reflect.TypeOf(Status{})
+	for i := 0; i < t.NumField(); i++ {
+		if name := t.Field(i).Tag.Get("status"); name != "" {
+			fields[name] = i
+		}
+	}
+	return fields
+}()
+
+// Status returns the decoded contents of /proc/PID/status.  The status is
+// read with StatusMap, passing refresh.
+// Status is only available on linux.
+func (p *Process) Status(refresh ...bool) (*Status, error) {
+	m, err := p.StatusMap(refresh...)
+	if err != nil {
+		return nil, err
+	}
+	st := &Status{}
+	sv := reflect.ValueOf(st).Elem()
+	extra := func(name string, value StatusValue) {
+		if st.Extras == nil {
+			st.Extras = map[string]StatusValue{}
+		}
+		st.Extras[name] = value
+	}
+	for name, value := range m {
+		typ, ok := statusTypes[name]
+		if !ok {
+			extra(name, value)
+			continue
+		}
+		i, ok := statusFields[name]
+		if !ok || typ == stIgnore {
+			continue
+		}
+		if err := decodeStatus(sv.Field(i), typ, value); err != nil {
+			sv.Field(i).Set(reflect.Zero(sv.Field(i).Type()))
+			extra(name, value)
+		}
+	}
+	return st, nil
+}
+
+var signalSetType = reflect.TypeOf(SignalSet(0))
+
+// decodeStatus decodes value, of type typ, into the field f.
+func decodeStatus(f reflect.Value, typ int, value StatusValue) error {
+	switch typ {
+	case stString:
+		f.SetString(string(value))
+	case stByte:
+		if len(value) > 0 {
+			f.SetUint(uint64(value[0]))
+		}
+	case stOctal, stDecimal:
+		var v int64
+		var err error
+		if typ == stOctal {
+			v, err = value.AsOctal()
+		} else {
+			v, err = value.AsDecimal()
+		}
+		if err != nil {
+			return err
+		}
+		f.SetInt(v)
+	case stHex:
+		if f.Type() == signalSetType {
+			set, err := parseSignalSet(value)
+			if err != nil {
+				return err
+			}
+			f.Set(reflect.ValueOf(set))
+			break
+		}
+		v, err := value.AsUnsignedHex()
+		if err != nil {
+			return err
+		}
+		f.SetUint(v)
+	case stSize:
+		v, err := value.AsSize("")
+		if err != nil {
+			return err
+		}
+		f.SetInt(v)
+	case stID:
+		creds, err := value.AsCreds()
+		if err != nil {
+			return err
+		}
+		f.Set(reflect.ValueOf(creds))
+	case stArray:
+		a, err := value.AsArray()
+		if err != nil {
+			return err
+		}
+		vs := make([]int, len(a))
+		for i, v := range a {
+			vs[i] = int(v)
+		}
+		f.Set(reflect.ValueOf(vs))
+	case stRange, stHexList:
+		var vs []int
+		var err error
+		if typ == stRange {
+			vs, err = value.AsRange()
+		} else {
+			vs, err = value.AsHexList()
+		}
+		if err != nil {
+			return err
+		}
+		f.Set(reflect.ValueOf(vs))
+	case stSlash:
+		n, m, err := value.AsSlash()
+		if err != nil {
+			return err
+		}
+		f.Set(reflect.ValueOf([2]int64{n, m}))
+	default:
+		return fmt.Errorf("unknown status type %d", typ)
+	}
+	return nil
+}
@@ -0,0 +1,265 @@
+//go:build linux
+
+package ps
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"testing"
+	"testing/fstest"
+)
+
+const testFullStatus = `Name:	cat
+Umask:	0022
+State:	R (running)
+Tgid:	29702
+Ngid:	0
+Pid:	29702
+PPid:	29697
+TracerPid:	0
+Uid:	1000	1000	1000	1000
+Gid:	100	100	100	100
+FDSize:	64
+Groups:	4 24 100
+NStgid:	29702	12
+NSpid:	29702	12
+NSpgid:	29702	12
+NSsid:	29697	1
+Kthread:	0
+VmPeak:	    2640 kB
+VmSize:	    2640 kB
+VmLck:	       0 kB
+VmPin:	       0 kB
+VmHWM:	    1252 kB
+VmRSS:	    1252 kB
+RssAnon:	     100 kB
+RssFile:	    1152 kB
+RssShmem:	       0 kB
+VmData:	     360 kB
+VmStk:	     132 kB
+VmExe:	      20 kB
+VmLib:	    1528 kB
+VmPTE:	      44 kB
+VmSwap:	       0 kB
+HugetlbPages:	       0 kB
+CoreDumping:	0
+THP_enabled:	1
+Threads:	1
+SigQ:	2/24002
+SigPnd:	0000000000000000
+ShdPnd:	0000000000000000
+SigBlk:	0000000000010000
+SigIgn:	0000000000001000
+SigCgt:	0000000000004002
+CapInh:	0000000000000000
+CapPrm:	0000000000000000
+CapEff:	0000000000000000
+CapBnd:	000001ffffffffff
+CapAmb:	0000000000000000
+NoNewPrivs:	1
+Seccomp:	2
+Seccomp_filters:	1
+Speculation_Store_Bypass:	thread vulnerable
+Cpus_allowed:	ff,0000000f
+Cpus_allowed_list:	0-3,32-39
+Mems_allowed:	00000000,00000001
+Mems_allowed_list:	0
+voluntary_ctxt_switches:	7
+nonvoluntary_ctxt_switches:	3
+`
+
+func TestStatus(t *testing.T) {
+	fsys := testProcFS()
+	fsys.fs["1/status"] = &fstest.MapFile{Data: []byte(testFullStatus)}
+	fsys.fs["3/status"].Data = append(fsys.fs["3/status"].Data, "VmRSS:\tlots kB\nSigCgt:\t000000000000000100000000000004ec\n"...)
+	src := NewSourceFS(fsys)
+
+	p, err := src.ProcessByPid(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	st, err := p.Status()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := &Status{
+		Name:                     "cat",
+		Umask:                    0022,
+		State:                    'R',
+		Tgid:                     29702,
+		Pid:                      29702,
+		PPid:                     29697,
+		Uid:                      Creds{1000, 1000, 1000, 1000},
+		Gid:                      Creds{100, 100, 100, 100},
+		FDSize:                   64,
+		Groups:                   []int{4, 24, 100},
+		NStgid:                   []int{29702, 12},
+		NSpid:                    []int{29702, 12},
+		NSpgid:                   []int{29702, 12},
+		NSsid:                    []int{29697, 1},
+		VmPeak:                   2640 * 1024,
+		VmSize:                   2640 * 1024,
+		VmHWM:                    1252 * 1024,
+		VmRSS:                    1252 * 1024,
+		RssAnon:                  100 * 1024,
+		RssFile:                  1152 * 1024,
+		VmData:                   360 * 1024,
+		VmStk:                    132 * 1024,
+		VmExe:                    20 * 1024,
+		VmLib:                    1528 * 1024,
+		VmPTE:                    44 * 1024,
+		Threads:                  1,
+		SigQ:                     [2]int64{2, 24002},
+		SigBlk:                   0x10000,
+		SigIgn:                   0x1000,
+		SigCgt:                   0x4002,
+		CapBnd:                   0x1ffffffffff,
+		NoNewPrivs:               1,
+		Seccomp:                  2,
+		SpeculationStoreBypass:   "thread vulnerable",
+		CpusAllowed:              []int{0, 1, 2, 3, 32, 33, 34, 35, 36, 37, 38, 39},
+		CpusAllowedList:          []int{0, 1, 2, 3, 32, 33, 34, 35, 36, 37, 38, 39},
+		MemsAllowed:              []int{0},
+		MemsAllowedList:          []int{0},
+		VoluntaryCtxtSwitches:    7,
+		NonvoluntaryCtxtSwitches: 3,
+		Extras: map[string]StatusValue{
+			"Kthread":         "0",
+			"THP_enabled":     "1",
+			"Seccomp_filters": "1",
+		},
+	}
+	if !reflect.DeepEqual(st, want) {
+		t.Errorf("Got status\n%+v\nwant\n%+v", st, want)
+	}
+
+	p, err = src.ProcessByPid(3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The invalid VmRSS line does not prevent the rest of the status, which
+	// includes a 128 bit mask as found on mips, from being decoded.
+	st, err = p.Status()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if st.Pid != 3 || st.Name != "kworker/0:1-events" || st.SigCgt != 0x4ec {
+		t.Errorf("Got status %+v", st)
+	}
+	if st.VmRSS != 0 || st.Extras["VmRSS"] != "lots kB" {
+		t.Errorf("Got VmRSS %d and extras %q", st.VmRSS, st.Extras)
+	}
+}
+
+// TestStatusTypes verifies that every line in statusTypes has a field in
+// Status that can hold it.
+func TestStatusTypes(t *testing.T) {
+	samples := map[int]StatusValue{
+		stString:  "x",
+		stOctal:   "0755",
+		stDecimal: "42",
+		stHex:     "ffffffffffffffff",
+		stSize:    "4 kB",
+		stByte:    "S (sleeping)",
+		stID:      "1 2 3 4",
+		stArray:   "1 2",
+		stRange:   "0-1",
+		stHexList: "3",
+		stSlash:   "1/2",
+	}
+	sv := reflect.ValueOf(&Status{}).Elem()
+	for name, typ := range statusTypes {
+		if typ == stIgnore {
+			continue
+		}
+		i, ok := statusFields[name]
+		if !ok {
+			t.Errorf("%s: no field in Status", name)
+			continue
+		}
+		func() {
+			defer func() {
+				if r := recover(); r != nil {
+					t.Errorf("%s: field %s cannot hold type %d: %v", name, sv.Type().Field(i).Name, typ, r)
+				}
+			}()
+			if err := decodeStatus(sv.Field(i), typ, samples[typ]); err != nil {
+				t.Errorf("%s: %v", name, err)
+			}
+		}()
+	}
+	for name := range statusFields {
+		if _, ok := statusTypes[name]; !ok {
+			t.Errorf("%s: field is not in statusTypes", name)
+		}
+	}
+}
+
+func TestStatusValueDecoders(t *testing.T) {
+	for _, tt := range []struct {
+		in   StatusValue
+		want string
+		ok   bool
+	}{
+		{"", "[]", true},
+		{"0", "[0]", true},
+		{"0-3,8,10-11", "[0 1 2 3 8 10 11]", true},
+		{"3-1", "[]", false},
+		{"0-x", "[]", false},
+	} {
+		got, err := tt.in.AsRange()
+		if (err == nil) != tt.ok || (tt.ok && fmt.Sprint(got) != tt.want) {
+			t.Errorf("AsRange(%q) got %v, %v, want %s", tt.in, got, err, tt.want)
+		}
+	}
+	for _, tt := range []struct {
+		in   StatusValue
+		want string
+		ok   bool
+	}{
+		{"", "[]", true},
+		{"1", "[0]", true},
+		{"ff", "[0 1 2 3 4 5 6 7]", true},
+		{"00000001,80000000", "[31 32]", true},
+		{"00000000,00000000,00000001", "[0]", true},
+		{"100000000", "[]", false},
+		{"zz", "[]", false},
+	} {
+		got, err := tt.in.AsHexList()
+		if (err == nil) != tt.ok || (tt.ok && fmt.Sprint(got) != tt.want) {
+			t.Errorf("AsHexList(%q) got %v, %v, want %s", tt.in, got, err, tt.want)
+		}
+	}
+	if n, m, err := StatusValue("0/63704").AsSlash(); err != nil || n != 0 || m != 63704 {
+		t.Errorf("AsSlash got %d, %d, %v", n, m, err)
+	}
+	for _, bad := range []StatusValue{"", "1", "x/1", "1/x"} {
+		if _, _, err := bad.AsSlash(); err == nil {
+			t.Errorf("AsSlash(%q) did not return an error", bad)
+		}
+	}
+	if v, err := StatusValue("ffffffffffffffff").AsUnsignedHex(); err != nil || v != ^uint64(0) {
+		t.Errorf("AsUnsignedHex got %#x, %v", v, err)
+	}
+}
+
+func TestStatusLive(t *testing.T) {
+	p := &Process{ID: mypid}
+	st, err := p.Status()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if st.Pid != mypid || st.Tgid != mypid || st.PPid != os.Getppid() {
+		t.Errorf("Got pid %d, tgid %d, ppid %d", st.Pid, st.Tgid, st.PPid)
+	}
+	if st.Uid.Real != os.Getuid() || st.Gid.Effective != os.Getegid() {
+		t.Errorf("Got uid %v, gid %v", st.Uid, st.Gid)
+	}
+	if st.Threads < 1 || st.VmRSS <= 0 || len(st.CpusAllowedList) == 0 {
+		t.Errorf("Got threads %d, rss %d, cpus %v", st.Threads, st.VmRSS, st.CpusAllowedList)
+	}
+	if fmt.Sprint(st.CpusAllowed) != fmt.Sprint(st.CpusAllowedList) {
+		t.Errorf("Cpus_allowed %v does not match Cpus_allowed_list %v", st.CpusAllowed, st.CpusAllowedList)
+	}
+}
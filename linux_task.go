@@ -0,0 +1,87 @@
+//go:build linux
+
+package ps
+
+import (
+	"strconv"
+)
+
+// Threads returns the tasks (threads) of p, as found in /proc/PID/task.  The
+// thread group leader, whose ID is the same as p's, is included.  The ID of
+// each returned Process is the thread ID of the task and its methods, such as
+// Stat, StatusMap and Command, return the information of that task.
+// Threads is only available on linux.
+func (p *Process) Threads() ([]*Process, error) {
+	names, err := p.source().readDirNames(p.dirname() + "/task")
+	if err != nil {
+		return nil, fixError(err)
+	}
+	threads := make([]*Process, 0, len(names))
+	for _, name := range names {
+		tid, err := strconv.Atoi(name)
+		if err != nil {
+			continue
+		}
+		threads = append(threads, &Process{
+			ID:   tid,
+			src:  p.src,
+			dir:  p.dirname() + "/task/" + name,
+			tgid: p.ID,
+		})
+	}
+	return threads, nil
+}
+
+// Tgid returns the thread group ID of p, which is the process ID of the
+// process a thread returned by Threads belongs to.  The thread group ID of a
+// process is its own ID.
+// Tgid is only available on linux.
+func (p *Process) Tgid() (int, error) {
+	if p.tgid != 0 {
+		return p.tgid, nil
+	}
+	v, err := p.StatusValue("Tgid")
+	if err != nil {
+		return 0, err
+	}
+	tgid, err := v.AsDecimal()
+	if err != nil {
+		return 0, err
+	}
+	p.tgid = int(tgid)
+	return p.tgid, nil
+}
+
+// Tasks returns the tasks of every process found in s.  Setting filled to true
+// will also stat the directory of each task.  Processes and tasks that exit
+// while being read are not returned.
+func (s *Source) Tasks(filled bool) ([]*Process, error) {
+	procs, err := s.Processes(false)
+	if err != nil {
+		return nil, err
+	}
+	var tasks []*Process
+	for _, p := range procs {
+		threads, err := p.Threads()
+		if err != nil {
+			continue
+		}
+		for _, t := range threads {
+			if filled {
+				if err := t.getStat(); err != nil {
+					continue
+				}
+			}
+			tasks = append(tasks, t)
+		}
+	}
+	return tasks, nil
+}
+
+// Tasks returns the tasks (threads) of every process on the system.  It is
+// the same as Processes but each thread of a process is returned as a
+// separate Process.  See Source.Tasks for details.
+// Tasks is only available on linux.
+func Tasks(filled bool) ([]*Process, error) {
+	return defaultSource.Tasks(filled)
+}
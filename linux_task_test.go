@@ -0,0 +1,103 @@
+//go:build linux
+
+package ps
+
+import (
+	"sort"
+	"testing"
+)
+
+func TestThreads(t *testing.T) {
+	src := NewSourceFS(testProcFS())
+	p, err := src.ProcessByPid(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	threads, err := p.Threads()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(threads) != 2 {
+		t.Fatalf("Got %d threads, want 2", len(threads))
+	}
+	sort.Slice(threads, func(i, j int) bool { return threads[i].ID < threads[j].ID })
+	th := threads[1]
+	if th.ID != 7 {
+		t.Fatalf("Got thread %d, want 7", th.ID)
+	}
+	s, err := th.Stat()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.Comm != "sd-worker" || s.State != 'R' || s.Utime != 60 || s.Stime != 30 {
+		t.Errorf("Got thread stat %+v", s)
+	}
+	if cmd, err := th.Command(); err != nil || cmd != "sd-worker" {
+		t.Errorf("Got thread command %q, %v", cmd, err)
+	}
+	sm, err := th.StatusMap()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sm["State"] != "R (running)" {
+		t.Errorf("Got thread state %q", sm["State"])
+	}
+	if tgid, err := th.Tgid(); err != nil || tgid != 1 {
+		t.Errorf("Got tgid %d, %v, want 1", tgid, err)
+	}
+
+	p, err = src.ProcessByPid(200)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := p.Threads(); err == nil {
+		t.Errorf("Got threads for a process without a task directory")
+	}
+	if tgid, err := p.Tgid(); err != nil || tgid != 200 {
+		t.Errorf("Got tgid %d, %v, want 200", tgid, err)
+	}
+}
+
+func TestTasks(t *testing.T) {
+	tasks, err := NewSourceFS(testProcFS()).Tasks(true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tasks) != 2 {
+		t.Fatalf("Got %d tasks, want 2", len(tasks))
+	}
+
+	tasks, err = Tasks(false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var found bool
+	for _, task := range tasks {
+		if task.ID == mypid {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("My PID was not found")
+	}
+}
+
+func TestThreadsLive(t *testing.T) {
+	p := &Process{ID: mypid}
+	threads, err := p.Threads()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var leader bool
+	for _, th := range threads {
+		if th.ID == mypid {
+			leader = true
+		}
+		if tgid, err := th.Tgid(); err != nil || tgid != mypid {
+			t.Errorf("Thread %d has tgid %d, %v", th.ID, tgid, err)
+		}
+	}
+	if !leader {
+		t.Errorf("Thread group leader not found")
+	}
+}
@@ -3,9 +3,14 @@
 package ps
 
 import (
+	"context"
+	"fmt"
 	"os"
+	"runtime"
 	"syscall"
 	"testing"
+	"testing/fstest"
+	"time"
 )
 
 var mypid = os.Getpid()
@@ -83,7 +88,20 @@ func TestProcesses(t *testing.T) {
 			t.Fatal(err)
 		}
 		if mypid == int(p.stat.Pid) {
-			if p.stat.State != 'R' {
+			// The stat of a process is that of its thread group leader,
+			// which need not be the thread running this test.
+			runtime.LockOSThread()
+			defer runtime.UnlockOSThread()
+			tasks := NewSource(fmt.Sprintf("/proc/%d/task", mypid))
+			th, err := tasks.ProcessByPid(syscall.Gettid())
+			if err != nil {
+				t.Fatal(err)
+			}
+			st, err := th.Stat()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if st.State != 'R' {
 				t.Errorf("I am not running")
 			}
 			return
@@ -174,3 +192,138 @@ func TestEPerm(t *testing.T) {
 		t.Errorf("Got %v, want %v", err, syscall.EPERM)
 	}
 }
+
+func TestStateFS(t *testing.T) {
+	src := NewSourceFS(testProcFS())
+	for _, tt := range []struct {
+		pid   int
+		state State
+	}{
+		{1, StateSleeping},
+		{3, StateIdle},
+		{200, StateZombie},
+	} {
+		p, err := src.ProcessByPid(tt.pid)
+		if err != nil {
+			t.Fatal(err)
+		}
+		state, err := p.State()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if state != tt.state {
+			t.Errorf("Process %d has state %v, want %v", tt.pid, state, tt.state)
+		}
+	}
+}
+
+func TestCredsFS(t *testing.T) {
+	src := NewSourceFS(fstest.MapFS{
+		"42/stat":   {Data: testStat(42, "passwd", 'S', 1, 0, 0, 10)},
+		"42/status": {Data: []byte("Name:\tpasswd\nUid:\t1000\t0\t0\t0\nGid:\t1000\t1000\t1000\t1000\n")},
+	})
+	p, err := src.ProcessByPid(42)
+	if err != nil {
+		t.Fatal(err)
+	}
+	creds, err := p.UidCreds()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := (Creds{Real: 1000}); creds != want {
+		t.Errorf("Got uid creds %+v, want %+v", creds, want)
+	}
+	euid, err := p.EffectiveUid()
+	if err != nil || euid != 0 {
+		t.Errorf("Got effective uid %d, %v, want 0", euid, err)
+	}
+	creds, err = p.GidCreds()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := (Creds{1000, 1000, 1000, 1000}); creds != want {
+		t.Errorf("Got gid creds %+v, want %+v", creds, want)
+	}
+}
+
+func TestCwdRootFS(t *testing.T) {
+	src := NewSourceFS(fstest.MapFS{
+		"42/stat": {Data: testStat(42, "nginx", 'S', 1, 0, 0, 10)},
+		"42/cwd":  testLink("/srv/www"),
+		"42/root": testLink("/var/lib/jail"),
+	})
+	p, err := src.ProcessByPid(42)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if dir, err := p.Cwd(); err != nil || dir != "/srv/www" {
+		t.Errorf("Got cwd %q, %v, want /srv/www", dir, err)
+	}
+	if dir, err := p.Root(); err != nil || dir != "/var/lib/jail" {
+		t.Errorf("Got root %q, %v, want /var/lib/jail", dir, err)
+	}
+	if _, err := (&Process{ID: 43, src: src}).Cwd(); err != syscall.ESRCH {
+		t.Errorf("Got %v for missing process, want %v", err, syscall.ESRCH)
+	}
+}
+
+func TestSignalReused(t *testing.T) {
+	_, p := startChild(t, "sleep", "60")
+	// ProcessByPid records the start time of p, which is kept when the
+	// information cached in p is cleared.  Stat is never called.
+	if !p.hasStart {
+		t.Fatalf("ProcessByPid did not record the start time")
+	}
+	p.Clean()
+	// Pretend p was obtained before its PID was reused.
+	p.start--
+	if err := p.Signal(syscall.SIGTERM); err != ErrReused {
+		t.Errorf("Got %v, want %v", err, ErrReused)
+	}
+	if err := p.Terminate(context.Background(), time.Minute); err != nil {
+		t.Errorf("Terminate of reused process: %v", err)
+	}
+	if state, err := (&Process{ID: p.ID}).State(); err != nil || state == StateZombie {
+		t.Errorf("Reused process was signalled: %v, %v", state, err)
+	}
+
+	pm := GetProcessMap()
+	if pm == nil {
+		t.Fatal("GetProcessMap failed")
+	}
+	if q := pm.Pids[p.ID]; q == nil || !q.hasStart {
+		t.Errorf("GetProcessMap did not record the start time of %d", p.ID)
+	}
+
+	// A thread other than the thread group leader cannot have a pidfd.
+	threads, err := (&Process{ID: mypid}).Threads()
+	if err != nil {
+		t.Fatal(err)
+	}
+	th := threads[len(threads)-1]
+	if _, err := th.Stat(); err != nil {
+		t.Fatal(err)
+	}
+	if err := th.Signal(0); err != nil {
+		t.Errorf("Signal thread %d: %v", th.ID, err)
+	}
+
+	q := &Process{ID: p.ID}
+	if err := q.Signal(0); err != ErrNoStartTime {
+		t.Errorf("Got %v, want %v", err, ErrNoStartTime)
+	}
+	if _, err := q.StartTime(); err != nil {
+		t.Fatal(err)
+	}
+	if err := q.Signal(0); err != nil {
+		t.Errorf("Signal after StartTime: %v", err)
+	}
+
+	p, err = NewSourceFS(testProcFS()).ProcessByPid(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := p.Signal(0); err != ErrNotLive {
+		t.Errorf("Got %v, want %v", err, ErrNotLive)
+	}
+}
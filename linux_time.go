@@ -0,0 +1,143 @@
+//go:build linux
+
+package ps
+
+import (
+	"bytes"
+	"errors"
+	"path"
+	"strconv"
+	"time"
+)
+
+// ClockTicks returns the number of clock ticks per second used by the kernel
+// when reporting times in /proc (USER_HZ, normally 100).
+// ClockTicks is only available on linux.
+func ClockTicks() int64 {
+	return clockTicks()
+}
+
+// ticksToDuration converts ticks clock ticks into a time.Duration.
+func ticksToDuration(ticks uint64) time.Duration {
+	hz := uint64(clockTicks())
+	return time.Duration(ticks/hz)*time.Second + time.Duration(ticks%hz)*time.Second/time.Duration(hz)
+}
+
+// UserTime returns the time s has been scheduled in user mode.
+func (s *Stat) UserTime() time.Duration {
+	return ticksToDuration(s.Utime)
+}
+
+// SystemTime returns the time s has been scheduled in kernel mode.
+func (s *Stat) SystemTime() time.Duration {
+	return ticksToDuration(s.Stime)
+}
+
+// ChildUserTime returns the time the waited-for children of s have been
+// scheduled in user mode.
+func (s *Stat) ChildUserTime() time.Duration {
+	return ticksToDuration(uint64(s.Cutime))
+}
+
+// ChildSystemTime returns the time the waited-for children of s have been
+// scheduled in kernel mode.
+func (s *Stat) ChildSystemTime() time.Duration {
+	return ticksToDuration(uint64(s.Cstime))
+}
+
+// BlkioDelay returns the aggregated block I/O delays of s.
+func (s *Stat) BlkioDelay() time.Duration {
+	return ticksToDuration(s.DelayacctBlkioTicks)
+}
+
+// GuestDuration returns the time spent running a virtual CPU for a guest
+// operating system.
+func (s *Stat) GuestDuration() time.Duration {
+	return ticksToDuration(s.GuestTime)
+}
+
+// ChildGuestDuration returns the time the children of s have spent running a
+// virtual CPU for a guest operating system.
+func (s *Stat) ChildGuestDuration() time.Duration {
+	return ticksToDuration(uint64(s.CguestTime))
+}
+
+// StartOffset returns the time s started after the system booted.
+func (s *Stat) StartOffset() time.Duration {
+	return ticksToDuration(s.Starttime)
+}
+
+// BootTime returns the time the system booted, as reported by the btime line
+// of the stat file in s.
+func (s *Source) BootTime() (time.Time, error) {
+	data, err := s.readFile(path.Join(s.root, "stat"))
+	if err != nil {
+		return time.Time{}, err
+	}
+	for _, line := range bytes.Split(data, []byte{'\n'}) {
+		f := bytes.Fields(line)
+		if len(f) != 2 || string(f[0]) != "btime" {
+			continue
+		}
+		secs, err := strconv.ParseInt(string(f[1]), 10, 64)
+		if err != nil {
+			return time.Time{}, err
+		}
+		return time.Unix(secs, 0), nil
+	}
+	return time.Time{}, errors.New("btime not found in stat")
+}
+
+// BootTime returns the time the system booted.
+// BootTime is only available on linux.
+func BootTime() (time.Time, error) {
+	return defaultSource.BootTime()
+}
+
+// uptime returns how long the system of s had been up, as reported by its
+// uptime file.
+func (s *Source) uptime() (time.Duration, error) {
+	data, err := s.readFile(path.Join(s.root, "uptime"))
+	if err != nil {
+		return 0, err
+	}
+	f := bytes.Fields(data)
+	if len(f) == 0 {
+		return 0, errors.New("empty uptime")
+	}
+	secs, err := strconv.ParseFloat(string(f[0]), 64)
+	if err != nil {
+		return 0, err
+	}
+	return time.Duration(secs * float64(time.Second)), nil
+}
+
+func (p *Process) startTime() (time.Time, error) {
+	s, err := p.Stat()
+	if err != nil {
+		return time.Time{}, err
+	}
+	boot, err := p.source().BootTime()
+	if err != nil {
+		return time.Time{}, err
+	}
+	return boot.Add(s.StartOffset()), nil
+}
+
+// Elapsed returns how long p has been running.  For captured sources the
+// elapsed time is relative to when the capture was made.
+// Elapsed is only available on linux.
+func (p *Process) Elapsed() (time.Duration, error) {
+	s, err := p.Stat()
+	if err != nil {
+		return 0, err
+	}
+	up, err := p.source().uptime()
+	if err != nil {
+		return 0, err
+	}
+	if e := up - s.StartOffset(); e > 0 {
+		return e, nil
+	}
+	return 0, nil
+}
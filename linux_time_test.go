@@ -0,0 +1,73 @@
+//go:build linux
+
+package ps
+
+import (
+	"testing"
+	"time"
+)
+
+func TestStatDurations(t *testing.T) {
+	hz := clockTicks()
+	s := &Stat{
+		Utime:               uint64(3 * hz),
+		Stime:               uint64(hz / 2),
+		Cutime:              hz,
+		Cstime:              2 * hz,
+		DelayacctBlkioTicks: uint64(hz / 4),
+		GuestTime:           uint64(5 * hz),
+		CguestTime:          6 * hz,
+		Starttime:           uint64(90 * hz),
+	}
+	for _, tt := range []struct {
+		name string
+		got  time.Duration
+		want time.Duration
+	}{
+		{"UserTime", s.UserTime(), 3 * time.Second},
+		{"SystemTime", s.SystemTime(), time.Second / 2},
+		{"ChildUserTime", s.ChildUserTime(), time.Second},
+		{"ChildSystemTime", s.ChildSystemTime(), 2 * time.Second},
+		{"BlkioDelay", s.BlkioDelay(), time.Second / 4},
+		{"GuestDuration", s.GuestDuration(), 5 * time.Second},
+		{"ChildGuestDuration", s.ChildGuestDuration(), 6 * time.Second},
+		{"StartOffset", s.StartOffset(), 90 * time.Second},
+	} {
+		if tt.got != tt.want {
+			t.Errorf("%s got %v, want %v", tt.name, tt.got, tt.want)
+		}
+	}
+}
+
+func TestStartTimeFS(t *testing.T) {
+	src := NewSourceFS(testProcFS())
+	boot, err := src.BootTime()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := time.Unix(1600000000, 0); !boot.Equal(want) {
+		t.Errorf("Got boot time %v, want %v", boot, want)
+	}
+
+	hz := uint64(clockTicks())
+	fsys := testProcFS()
+	fsys.fs["100/stat"].Data = testStat(100, "systemd-timesyn", 'S', 1, 10, 5, 400*hz)
+	p, err := NewSourceFS(fsys).ProcessByPid(100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	start, err := p.StartTime()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := boot.Add(400 * time.Second); !start.Equal(want) {
+		t.Errorf("Got start time %v, want %v", start, want)
+	}
+	elapsed, err := p.Elapsed()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if elapsed != 600*time.Second {
+		t.Errorf("Got elapsed %v, want 10m0s", elapsed)
+	}
+}
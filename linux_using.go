@@ -0,0 +1,139 @@
+//go:build linux
+
+package ps
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// A UseKind is the way a process uses a file.
+// UseKind is only available on linux.
+type UseKind int
+
+const (
+	UseFD     = UseKind(iota + 1) // The file is open
+	UseCwd                        // The file is the current working directory
+	UseRoot                       // The file is the root directory
+	UseExe                        // The file is the executable
+	UseMapped                     // The file is memory mapped
+)
+
+var useKindNames = []string{
+	UseFD:     "fd",
+	UseCwd:    "cwd",
+	UseRoot:   "root",
+	UseExe:    "exe",
+	UseMapped: "mmap",
+}
+
+func (k UseKind) String() string {
+	if k > 0 && int(k) < len(useKindNames) {
+		return useKindNames[k]
+	}
+	return fmt.Sprintf("UseKind(%d)", int(k))
+}
+
+// A Use is a single use of a file by a process.
+// Use is only available on linux.
+type Use struct {
+	Process *Process
+	Kind    UseKind
+	FD      int    // The file descriptor, only set for UseFD
+	Path    string // The pathname of the file used
+}
+
+// ProcessesUsing returns the uses of path by the processes in s.  A process
+// uses a file if it has it open, mapped into memory, as its executable, or as
+// its current or root directory.  If path is a directory then any use of a
+// file beneath it is also reported.  This is a directory prefix match, not a
+// match of a file system: passing a mount point also reports uses of file
+// systems mounted beneath it, and does not report uses of the same file system
+// through a bind mount elsewhere.  Processes that cannot be inspected,
+// normally because they belong to a different user, are skipped.
+//
+// Files are matched by pathname, not by device and inode, so a file is only
+// found if the process sees it at the same pathname as the caller.  Uses by
+// processes with a different root directory or in a different mount
+// namespace are normally not reported.  The kernel reports the files used by
+// a process by their canonical pathnames, so when s reads the live /proc,
+// symbolic links in path are resolved if path exists.  They are not resolved
+// for any other Source, such as one from NewSourceFS or LoadCapture, as the
+// files of its processes need not be those of the caller.
+func (s *Source) ProcessesUsing(path string) ([]Use, error) {
+	path, err := filepath.Abs(path)
+	if err != nil {
+		return nil, err
+	}
+	if s.fsys == nil && filepath.Clean(s.root) == "/proc" {
+		if resolved, err := filepath.EvalSymlinks(path); err == nil {
+			path = resolved
+		}
+	}
+	procs, err := s.Processes(false)
+	if err != nil {
+		return nil, err
+	}
+	var uses []Use
+	for _, p := range procs {
+		uses = append(uses, p.uses(path)...)
+	}
+	return uses, nil
+}
+
+// ProcessesUsing returns the uses of path by processes on the system.  See
+// Source.ProcessesUsing for details.
+// ProcessesUsing is only available on linux.
+func ProcessesUsing(path string) ([]Use, error) {
+	return defaultSource.ProcessesUsing(path)
+}
+
+// uses returns the uses of path, or any file beneath it, by p.
+func (p *Process) uses(path string) []Use {
+	var uses []Use
+	add := func(kind UseKind, fd int, target string) {
+		target = strings.TrimSuffix(target, " (deleted)")
+		if under(target, path) {
+			uses = append(uses, Use{Process: p, Kind: kind, FD: fd, Path: target})
+		}
+	}
+	for _, link := range []struct {
+		name string
+		kind UseKind
+	}{
+		{"cwd", UseCwd},
+		{"root", UseRoot},
+		{"exe", UseExe},
+	} {
+		if target, err := p.source().readlink(p.dirname() + "/" + link.name); err == nil {
+			add(link.kind, 0, target)
+		}
+	}
+	if fds, err := p.FDs(); err == nil {
+		for _, fd := range fds {
+			if target := fd.Path(); target != "" {
+				add(UseFD, fd.FD, target)
+			}
+		}
+	}
+	if maps, err := p.Maps(); err == nil {
+		seen := map[string]bool{}
+		for _, m := range maps {
+			if !m.IsFileBacked() || seen[m.Path] || !strings.HasPrefix(m.Path, "/") {
+				continue
+			}
+			seen[m.Path] = true
+			add(UseMapped, 0, m.Path)
+		}
+	}
+	return uses
+}
+
+// under returns true if name is dir or is beneath dir.
+func under(name, dir string) bool {
+	if name == dir || dir == "/" {
+		return strings.HasPrefix(name, "/")
+	}
+	return strings.HasPrefix(name, dir+"/")
+}
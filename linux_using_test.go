@@ -0,0 +1,112 @@
+//go:build linux
+
+package ps
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"testing"
+	"testing/fstest"
+)
+
+func TestProcessesUsing(t *testing.T) {
+	fsys := testProcFS()
+	fsys.fs["1/cwd"] = testLink("/")
+	fsys.fs["1/root"] = testLink("/")
+	fsys.fs["1/fd/1"] = testLink("/var/log/syslog")
+	fsys.fs["1/fd/2"] = testLink("/var/log/old.log (deleted)")
+	fsys.fs["1/maps"] = &fstest.MapFile{Data: []byte(testMaps)}
+	fsys.fs["100/cwd"] = testLink("/var/lib/systemd/timesync")
+	fsys.fs["100/root"] = testLink("/")
+	fsys.fs["100/maps"] = &fstest.MapFile{Data: []byte(
+		"7f1e2a000000-7f1e2a001000 rw-s 00000000 08:01 999 /var/lib/systemd/timesync/clock\n" +
+			"7f1e2a001000-7f1e2a002000 r--s 00000000 08:01 999 /var/lib/systemd/timesync/clock\n")}
+	src := NewSourceFS(fsys)
+
+	for _, tt := range []struct {
+		path string
+		want []string
+	}{
+		{"/var/log/syslog", []string{"1 fd 1 /var/log/syslog"}},
+		{"/var/log", []string{"1 fd 1 /var/log/syslog", "1 fd 2 /var/log/old.log"}},
+		{"/var/lib/systemd", []string{
+			"100 cwd 0 /var/lib/systemd/timesync",
+			"100 mmap 0 /var/lib/systemd/timesync/clock",
+		}},
+		{"/usr/bin/cat", []string{"1 mmap 0 /usr/bin/cat"}},
+		{"/usr/lib/systemd/systemd", []string{"1 exe 0 /usr/lib/systemd/systemd"}},
+		{"/var/lo", nil},
+		{"/nowhere", nil},
+	} {
+		uses, err := src.ProcessesUsing(tt.path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got []string
+		for _, u := range uses {
+			got = append(got, fmt.Sprintf("%d %v %d %s", u.Process.ID, u.Kind, u.FD, u.Path))
+		}
+		if fmt.Sprint(got) != fmt.Sprint(tt.want) {
+			t.Errorf("%s: got %q, want %q", tt.path, got, tt.want)
+		}
+	}
+
+	uses, err := src.ProcessesUsing("/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	kinds := map[UseKind]int{}
+	for _, u := range uses {
+		kinds[u.Kind]++
+	}
+	if kinds[UseRoot] != 2 || kinds[UseCwd] != 2 || kinds[UseExe] != 1 {
+		t.Errorf("Got uses of / %v", kinds)
+	}
+}
+
+func TestProcessesUsingLive(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ps-using")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	f, err := os.Create(dir + "/file")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	uses, err := ProcessesUsing(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(uses) != 1 || uses[0].Process.ID != mypid || uses[0].Kind != UseFD || uses[0].FD != int(f.Fd()) {
+		t.Errorf("Got uses %+v", uses)
+	}
+
+	// The file is found through a symbolic link to its directory.
+	link := dir + "/link"
+	if err := os.Symlink(dir, link); err != nil {
+		t.Fatal(err)
+	}
+	uses, err = ProcessesUsing(link + "/file")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(uses) != 1 || uses[0].Process.ID != mypid || uses[0].FD != int(f.Fd()) {
+		t.Errorf("Got uses through link %+v", uses)
+	}
+
+	// The link is not resolved for processes that are not live, which may
+	// see a directory where the caller sees the link.
+	fsys := testProcFS()
+	fsys.fs["1/fd/3"] = testLink(link + "/file")
+	uses, err = NewSourceFS(fsys).ProcessesUsing(link + "/file")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(uses) != 1 || uses[0].Process.ID != 1 || uses[0].FD != 3 {
+		t.Errorf("Got uses of snapshot %+v", uses)
+	}
+}
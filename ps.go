@@ -7,6 +7,7 @@ package ps
 import (
 	"fmt"
 	"strings"
+	"time"
 )
 
 // An ErrUnset is returned when requesting the value of a variable that is not
@@ -56,6 +57,12 @@ func ProcessByName(name string) ([]*Process, error) {
 	if err != nil {
 		return nil, err
 	}
+	return matchName(ps, name), nil
+}
+
+// matchName returns the processes in ps whose name matches name as described
+// by ProcessByName.
+func matchName(ps []*Process, name string) []*Process {
 	var procs []*Process
 
 	switch strings.Index(name, "/") {
@@ -90,7 +97,46 @@ func ProcessByName(name string) ([]*Process, error) {
 			}
 		}
 	}
-	return procs, nil
+	return procs
+}
+
+// A State is the run state of a process.
+type State int
+
+const (
+	StateUnknown  = State(iota) // The state could not be determined
+	StateRunning                // Running or runnable
+	StateSleeping               // Interruptible sleep
+	StateDiskWait               // Uninterruptible sleep, usually disk I/O
+	StateStopped                // Stopped by a signal or a debugger
+	StateZombie                 // Exited but not yet waited for
+	StateIdle                   // An idle kernel thread
+)
+
+var stateNames = []string{
+	StateUnknown:  "unknown",
+	StateRunning:  "running",
+	StateSleeping: "sleeping",
+	StateDiskWait: "disk-wait",
+	StateStopped:  "stopped",
+	StateZombie:   "zombie",
+	StateIdle:     "idle",
+}
+
+func (s State) String() string {
+	if s >= 0 && int(s) < len(stateNames) {
+		return stateNames[s]
+	}
+	return fmt.Sprintf("State(%d)", int(s))
+}
+
+// Creds contains the credentials for a process.  The file system id is only
+// distinct on linux; on other systems it is the same as the effective id.
+type Creds struct {
+	Real      int
+	Effective int
+	Saved     int
+	FS        int
 }
 
 // Argv returns p's arguments.  Non-root users will receive an error when
@@ -109,6 +155,21 @@ func (p *Process) Command() (string, error) {
 	return p.command()
 }
 
+// CPUTime returns the total CPU time, user and system, used by p.
+func (p *Process) CPUTime() (time.Duration, error) {
+	return p.cpuTime()
+}
+
+// EffectiveUid returns the effective user id of p, which is the user id used
+// for permission checks.  It differs from Uid for setuid programs.
+func (p *Process) EffectiveUid() (int, error) {
+	creds, err := p.uidCreds()
+	if err != nil {
+		return 0, err
+	}
+	return creds.Effective, nil
+}
+
 // Environ returns a map of p's environment variables at time of launch.
 // Non-root users will receive an error when requesting information about a
 // process with a different UID.
@@ -117,7 +178,9 @@ func (p *Process) Environ() (map[string]string, error) {
 }
 
 // Footprint returns the phsycial memory footprint of p in bytes.
-// Pass in the value "true" to refresh the information.
+// Pass in the value "true" to refresh the information.  On linux the footprint
+// is read afresh from /proc/PID/smaps_rollup whenever it is readable, so
+// refresh only matters when it is not.
 func (p *Process) Footprint(refresh ...bool) (int, error) {
 	return p.footprint(refresh...)
 }
@@ -127,6 +190,11 @@ func (p *Process) Gid() (int, error) {
 	return p.gid()
 }
 
+// GidCreds returns the real, effective, saved and file system group ids of p.
+func (p *Process) GidCreds() (Creds, error) {
+	return p.gidCreds()
+}
+
 // Groups returns the list of groups the process is in
 func (p *Process) Groups() ([]int, error) {
 	return p.groups()
@@ -147,6 +215,16 @@ func (p *Process) Ppid() (int, error) {
 	return p.ppid()
 }
 
+// StartTime returns the time p was started.
+func (p *Process) StartTime() (time.Time, error) {
+	return p.startTime()
+}
+
+// State returns the run state of p.
+func (p *Process) State() (State, error) {
+	return p.state()
+}
+
 // Tty returns the controlling tty associated with p.  "-" is returned if there
 // is no associated tty.
 func (p *Process) Tty() (string, error) {
@@ -158,6 +236,11 @@ func (p *Process) Uid() (int, error) {
 	return p.uid()
 }
 
+// UidCreds returns the real, effective, saved and file system user ids of p.
+func (p *Process) UidCreds() (Creds, error) {
+	return p.uidCreds()
+}
+
 // Value returns the value p's environment variable name.
 func (p *Process) Value(name string) (string, error) {
 	return p.value(name)
@@ -173,6 +256,10 @@ func ProcessByPid(pid int) (*Process, error) {
 // true will also gather the kproc_info structures for each process.  This is
 // much more efficient than requesting the kproc_info structure for each
 // process.
+//
+// Processes returns only processes (thread group leaders).  Its signature is
+// shared by every platform, so rather than adding an option to include
+// threads, linux provides Tasks, which returns each thread as a Process.
 func Processes(filled bool) ([]*Process, error) {
 	return processes(filled)
 }
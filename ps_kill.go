@@ -0,0 +1,309 @@
+package ps
+
+import (
+	"context"
+	"errors"
+	"os"
+	"sort"
+	"syscall"
+	"time"
+)
+
+// ErrReused is returned when attempting to signal a process whose PID now
+// belongs to a different process.
+var ErrReused = errors.New("process ID has been reused")
+
+// ErrNoStartTime is returned when attempting to signal a process whose start
+// time was never read.
+var ErrNoStartTime = errors.New("process start time is not known")
+
+// Signal sends sig to p.  The identity of p is its start time, which is
+// recorded the first time information about p is read, as it is by
+// ProcessByPid, GetProcessMap and StartTime.  Signal returns ErrNoStartTime if
+// it was never read, such as for a Process returned by Processes(false), and
+// ErrReused if the process now using the ID of p started at a different time.
+// In either case sig is not sent.  The ID of p must be valid in the pid
+// namespace of the caller.
+//
+// On linux, when the kernel supports it, p is checked and signalled through a
+// pidfd, so the ID of p cannot be reused between the check and the signal.
+// ErrNotLive is returned if p was read from a Source created by NewSourceFS.
+func (p *Process) Signal(sig syscall.Signal) error {
+	return p.signal(sig)
+}
+
+// Terminate sends SIGTERM to p and waits for it to exit.  If p has not exited
+// after grace, SIGKILL is sent and Terminate waits for p to exit.  Terminate
+// returns nil once p has exited, including if p had already exited, or its
+// PID was reused, before Terminate was called.  A process that has exited but
+// not yet been waited for by its parent is considered to have exited.
+//
+// If ctx is done before p exits, Terminate returns ctx.Err() without sending
+// any further signals.
+func (p *Process) Terminate(ctx context.Context, grace time.Duration) error {
+	if err := p.Signal(syscall.SIGTERM); err != nil {
+		if err == syscall.ESRCH || err == ErrReused {
+			return nil
+		}
+		return err
+	}
+	timer := time.NewTimer(grace)
+	defer timer.Stop()
+	done, err := p.wait(ctx, timer.C)
+	if done || err != nil {
+		return err
+	}
+	if err := p.Signal(syscall.SIGKILL); err != nil {
+		if err == syscall.ESRCH || err == ErrReused {
+			return nil
+		}
+		return err
+	}
+	_, err = p.wait(ctx, nil)
+	return err
+}
+
+// Poll intervals used while waiting for a process to exit.
+const (
+	minPollInterval = 5 * time.Millisecond
+	maxPollInterval = 100 * time.Millisecond
+)
+
+// wait polls p until it exits, ctx is done, or timeout fires.  It reports
+// whether p exited.
+func (p *Process) wait(ctx context.Context, timeout <-chan time.Time) (bool, error) {
+	interval := minPollInterval
+	for {
+		if p.exited() {
+			return true, nil
+		}
+		t := time.NewTimer(interval)
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return false, ctx.Err()
+		case <-timeout:
+			t.Stop()
+			return p.exited(), nil
+		case <-t.C:
+		}
+		if interval *= 2; interval > maxPollInterval {
+			interval = maxPollInterval
+		}
+	}
+}
+
+// exited reports whether p has exited.  A zombie has exited.
+func (p *Process) exited() bool {
+	if err := syscall.Kill(p.ID, 0); err == syscall.ESRCH {
+		return true
+	}
+	q, err := p.current()
+	switch err {
+	case nil:
+	case syscall.ESRCH, ErrReused:
+		return true
+	default:
+		return false
+	}
+	state, err := q.State()
+	return err == nil && state == StateZombie
+}
+
+// KillTreeOptions are the options to KillTree.
+type KillTreeOptions struct {
+	// Cgroup causes the tree to be frozen with the cgroup freezer, by
+	// freezing the cgroup of the root process, rather than by sending
+	// SIGSTOP to each process.  Every process in the cgroup is frozen, not
+	// just those in the tree, so Cgroup is best used when the tree has a
+	// cgroup of its own, such as a container or a systemd unit.  Processes
+	// in the tree that are not in the cgroup are not frozen.  The cgroup
+	// must not contain the calling process.  Cgroup is only supported on
+	// linux.
+	Cgroup bool
+}
+
+// A KillTreeResult reports the outcome of KillTree for each process in the
+// tree.
+type KillTreeResult struct {
+	Signalled []int // Processes sent the signal, in the order signalled
+	Exited    []int // Processes that exited before they could be signalled
+	Denied    []int // Processes that could not be signalled (EPERM)
+}
+
+// maxTreeScans is the most times KillTree scans for new processes while
+// freezing a tree.
+const maxTreeScans = 100
+
+// KillTree sends sig to the process pid and all of its descendants.
+//
+// Signalling the descendants returned by GetDecendents one at a time races
+// with the tree: a process can fork after the scan, and the children of a
+// process that exits are reparented out of the tree.  KillTree instead
+// first freezes the tree by sending SIGSTOP to each process found and
+// scanning for new processes until a scan finds none.  A process found
+// while freezing stays in the tree even if it is later reparented.  The
+// processes are then sent sig, children before their parents, and finally
+// sent SIGCONT so that stopped processes act on sig.  If sig is SIGSTOP the
+// processes are left stopped.  A process that was stopped before KillTree
+// was called is also resumed.
+//
+// The calling process and its descendants are never signalled.  KillTree
+// returns syscall.ESRCH if pid does not exist and syscall.EINVAL if pid is
+// the calling process.  If the tree is still growing after many scans, the
+// processes found are signalled and an error is returned.  Processes that
+// cannot be signalled because they exited or are owned by a different user
+// are reported in the result rather than as an error.  opts may be nil.
+func KillTree(pid int, sig syscall.Signal, opts *KillTreeOptions) (*KillTreeResult, error) {
+	if opts == nil {
+		opts = &KillTreeOptions{}
+	}
+	self := os.Getpid()
+	if pid == self {
+		return nil, syscall.EINVAL
+	}
+	k := &treeKiller{
+		self:   self,
+		stop:   !opts.Cgroup,
+		procs:  map[int]*Process{},
+		parent: map[int]int{},
+		skip:   map[int]bool{},
+	}
+	var thaw func() error
+	if opts.Cgroup {
+		var err error
+		if thaw, err = freezeCgroup(pid); err != nil {
+			return nil, err
+		}
+	}
+	err := k.freeze(pid)
+	if len(k.order) > 0 {
+		k.signal(sig)
+	}
+	if sig != syscall.SIGSTOP {
+		k.resume()
+	}
+	if thaw != nil {
+		if terr := thaw(); err == nil {
+			err = terr
+		}
+	}
+	if err == syscall.ESRCH && len(k.order) == 0 {
+		return nil, err
+	}
+	if err == nil {
+		err = k.err
+	}
+	sort.Ints(k.result.Exited)
+	sort.Ints(k.result.Denied)
+	return &k.result, err
+}
+
+// A treeKiller holds the state of KillTree.
+type treeKiller struct {
+	self    int              // The calling process
+	stop    bool             // Send SIGSTOP to each process found
+	procs   map[int]*Process // The processes in the tree
+	parent  map[int]int      // The parent of each process when found
+	order   []int            // The processes in the order found
+	skip    map[int]bool     // Processes that are not to be signalled
+	stopped []int            // Processes sent SIGSTOP
+	result  KillTreeResult   // What happened to each process
+	err     error            // The first unexpected error from signalling
+}
+
+// freeze finds the tree rooted at root, stopping each process as it is found,
+// until a scan of the processes finds no new processes in the tree.
+func (k *treeKiller) freeze(root int) error {
+	for scan := 0; ; scan++ {
+		if scan == maxTreeScans {
+			return errors.New("process tree did not stop growing")
+		}
+		procs, err := Processes(true)
+		if err != nil {
+			return err
+		}
+		pm := newProcessMap(procs)
+		n := len(k.order)
+		if scan == 0 {
+			p := pm.Pids[root]
+			if p == nil {
+				return syscall.ESRCH
+			}
+			k.add(p, 0)
+		}
+		// k.order grows as processes are added, picking up their
+		// children in the same scan.
+		for i := 0; i < len(k.order); i++ {
+			ppid := k.order[i]
+			for _, pid := range pm.Children[ppid] {
+				if pid == k.self || k.procs[pid] != nil {
+					continue
+				}
+				k.add(pm.Pids[pid], ppid)
+			}
+		}
+		if len(k.order) == n {
+			return nil
+		}
+	}
+}
+
+// add adds p, a child of ppid, to the tree, stopping p if k.stop is set.
+func (k *treeKiller) add(p *Process, ppid int) {
+	k.procs[p.ID] = p
+	k.parent[p.ID] = ppid
+	k.order = append(k.order, p.ID)
+	if !k.stop {
+		return
+	}
+	if k.send(p, syscall.SIGSTOP) {
+		k.stopped = append(k.stopped, p.ID)
+	}
+}
+
+// signal sends sig to each process in the tree, deepest first.
+func (k *treeKiller) signal(sig syscall.Signal) {
+	depth := make(map[int]int, len(k.order))
+	for _, pid := range k.order {
+		for ppid := k.parent[pid]; ppid != 0; ppid = k.parent[ppid] {
+			depth[pid]++
+		}
+	}
+	pids := append([]int(nil), k.order...)
+	sort.SliceStable(pids, func(i, j int) bool {
+		return depth[pids[i]] > depth[pids[j]]
+	})
+	for _, pid := range pids {
+		if !k.skip[pid] && k.send(k.procs[pid], sig) {
+			k.result.Signalled = append(k.result.Signalled, pid)
+		}
+	}
+}
+
+// resume sends SIGCONT to each process stopped by k.
+func (k *treeKiller) resume() {
+	for _, pid := range k.stopped {
+		k.procs[pid].Signal(syscall.SIGCONT)
+	}
+}
+
+// send sends sig to p and reports whether it was sent.  A process that cannot
+// be signalled is recorded in the result and not signalled again.
+func (k *treeKiller) send(p *Process, sig syscall.Signal) bool {
+	err := p.Signal(sig)
+	switch err {
+	case nil:
+		return true
+	case syscall.ESRCH, ErrReused:
+		k.result.Exited = append(k.result.Exited, p.ID)
+	case syscall.EPERM:
+		k.result.Denied = append(k.result.Denied, p.ID)
+	default:
+		if k.err == nil {
+			k.err = err
+		}
+	}
+	k.skip[p.ID] = true
+	return false
+}
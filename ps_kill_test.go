@@ -0,0 +1,164 @@
+package ps
+
+import (
+	"context"
+	"os/exec"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// startChild starts name with args and returns it and its Process.  The child
+// is killed and waited for when the test ends.
+func startChild(t *testing.T, name string, args ...string) (*exec.Cmd, *Process) {
+	t.Helper()
+	return startCmd(t, exec.Command(name, args...))
+}
+
+func startCmd(t *testing.T, cmd *exec.Cmd) (*exec.Cmd, *Process) {
+	t.Helper()
+	if err := cmd.Start(); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		cmd.Process.Kill()
+		cmd.Wait()
+	})
+	p, err := ProcessByPid(cmd.Process.Pid)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cmd, p
+}
+
+// startStubborn starts a shell that ignores SIGTERM and waits for it to do so.
+func startStubborn(t *testing.T) (*exec.Cmd, *Process) {
+	t.Helper()
+	cmd := exec.Command("sh", "-c", "trap '' TERM; echo ready; while :; do sleep 1; done")
+	out, err := cmd.StdoutPipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	cmd, p := startCmd(t, cmd)
+	if _, err := out.Read(make([]byte, 1)); err != nil {
+		t.Fatal(err)
+	}
+	return cmd, p
+}
+
+func TestSignal(t *testing.T) {
+	cmd, p := startChild(t, "sleep", "60")
+	if err := p.Signal(syscall.SIGTERM); err != nil {
+		t.Fatal(err)
+	}
+	cmd.Wait()
+	if ws := cmd.ProcessState.Sys().(syscall.WaitStatus); !ws.Signaled() || ws.Signal() != syscall.SIGTERM {
+		t.Errorf("Got wait status %v, want killed by SIGTERM", ws)
+	}
+	if err := p.Signal(syscall.SIGTERM); err != syscall.ESRCH {
+		t.Errorf("Got %v signalling exited process, want %v", err, syscall.ESRCH)
+	}
+}
+
+func TestTerminate(t *testing.T) {
+	_, p := startChild(t, "sleep", "60")
+	start := time.Now()
+	if err := p.Terminate(context.Background(), time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	if d := time.Since(start); d > 30*time.Second {
+		t.Errorf("Terminate took %v", d)
+	}
+	// Terminating a process that has exited succeeds.
+	if err := p.Terminate(context.Background(), time.Minute); err != nil {
+		t.Errorf("Terminate of exited process: %v", err)
+	}
+}
+
+func TestTerminateKill(t *testing.T) {
+	cmd, p := startStubborn(t)
+	if err := p.Terminate(context.Background(), 50*time.Millisecond); err != nil {
+		t.Fatal(err)
+	}
+	cmd.Wait()
+	if ws := cmd.ProcessState.Sys().(syscall.WaitStatus); !ws.Signaled() || ws.Signal() != syscall.SIGKILL {
+		t.Errorf("Got wait status %v, want killed by SIGKILL", ws)
+	}
+}
+
+func TestTerminateContext(t *testing.T) {
+	_, p := startStubborn(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if err := p.Terminate(ctx, time.Minute); err != context.DeadlineExceeded {
+		t.Errorf("Got %v, want %v", err, context.DeadlineExceeded)
+	}
+	if state, err := (&Process{ID: p.ID}).State(); err != nil || state == StateZombie {
+		t.Errorf("Process exited after cancelled Terminate: %v, %v", state, err)
+	}
+}
+
+func TestKillTree(t *testing.T) {
+	// The subshell prints ready once every process in the tree is started.
+	cmd := exec.Command("sh", "-c", "sleep 60 & (sleep 60 & echo ready; wait) & wait")
+	out, err := cmd.StdoutPipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	cmd, p := startCmd(t, cmd)
+	if _, err := out.Read(make([]byte, 1)); err != nil {
+		t.Fatal(err)
+	}
+	pm := GetProcessMap()
+	tree := append(pm.GetDecendents(p.ID), p.ID)
+	if len(tree) < 4 {
+		t.Fatalf("Got tree %v, want at least 4 processes", tree)
+	}
+
+	res, err := KillTree(p.ID, syscall.SIGTERM, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Exited) != 0 || len(res.Denied) != 0 {
+		t.Errorf("Got exited %v and denied %v, want none", res.Exited, res.Denied)
+	}
+	order := map[int]int{}
+	for i, pid := range res.Signalled {
+		order[pid] = i
+	}
+	if len(order) != len(tree) {
+		t.Fatalf("Signalled %v, want %v", res.Signalled, tree)
+	}
+	for _, pid := range tree {
+		i, ok := order[pid]
+		if !ok {
+			t.Errorf("Process %d was not signalled", pid)
+			continue
+		}
+		ppid, _ := pm.Pids[pid].Ppid()
+		if j, ok := order[ppid]; ok && j < i {
+			t.Errorf("Process %d signalled after its parent %d", pid, ppid)
+		}
+	}
+
+	cmd.Wait()
+	if ws := cmd.ProcessState.Sys().(syscall.WaitStatus); !ws.Signaled() || ws.Signal() != syscall.SIGTERM {
+		t.Errorf("Got wait status %v, want killed by SIGTERM", ws)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	for _, pid := range tree {
+		if exited, err := (&Process{ID: pid}).wait(ctx, nil); !exited {
+			t.Errorf("Process %d did not exit: %v", pid, err)
+		}
+	}
+}
+
+func TestKillTreeErrors(t *testing.T) {
+	if _, err := KillTree(mypid, syscall.SIGTERM, nil); err != syscall.EINVAL {
+		t.Errorf("KillTree of self: got %v, want %v", err, syscall.EINVAL)
+	}
+	if _, err := KillTree(1<<30, syscall.SIGTERM, nil); err != syscall.ESRCH {
+		t.Errorf("KillTree of invalid PID: got %v, want %v", err, syscall.ESRCH)
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"strings"
 	"syscall"
 	"testing"
+	"time"
 )
 
 func TestPid(t *testing.T) {
@@ -48,6 +49,39 @@ func TestGid(t *testing.T) {
 	}
 }
 
+func TestUidCreds(t *testing.T) {
+	p := &Process{ID: mypid}
+	creds, err := p.UidCreds()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if creds.Real != os.Getuid() || creds.Effective != os.Geteuid() {
+		t.Errorf("Got uid creds %+v, want real %d and effective %d", creds, os.Getuid(), os.Geteuid())
+	}
+	euid, err := p.EffectiveUid()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if euid != os.Geteuid() {
+		t.Errorf("Got effective uid %d, want %d", euid, os.Geteuid())
+	}
+	p = &Process{ID: 1234567}
+	if _, err := p.UidCreds(); err != syscall.ESRCH {
+		t.Errorf("invalid PID did not return ESRCH: %T %v", err, err)
+	}
+}
+
+func TestGidCreds(t *testing.T) {
+	p := &Process{ID: mypid}
+	creds, err := p.GidCreds()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if creds.Real != os.Getgid() || creds.Effective != os.Getegid() {
+		t.Errorf("Got gid creds %+v, want real %d and effective %d", creds, os.Getgid(), os.Getegid())
+	}
+}
+
 func TestGroups(t *testing.T) {
 	p := &Process{ID: mypid}
 	groups, err := p.Groups()
@@ -76,17 +110,23 @@ func TestFootprint(t *testing.T) {
 	if err != nil {
 		t.Fatal(err)
 	}
-	// Force our footprint to increase
-	extra := make([]int, 16*1024*1024)
-	second, err := p.Footprint(true)
+	// Force our footprint to increase with memory that cannot already be
+	// resident.
+	const size = 64 << 20
+	extra, err := syscall.Mmap(-1, 0, size, syscall.PROT_READ|syscall.PROT_WRITE, syscall.MAP_ANON|syscall.MAP_PRIVATE)
 	if err != nil {
 		t.Fatal(err)
 	}
-	if first == second {
-		t.Errorf("Footprint did not change from %d", first)
+	defer syscall.Munmap(extra)
+	for i := 0; i < size; i += os.Getpagesize() {
+		extra[i] = 1
+	}
+	second, err := p.Footprint(true)
+	if err != nil {
+		t.Fatal(err)
 	}
-	if extra[0] != 0 {
-		t.Fatalf("this cannot happen")
+	if second < first+size/2 {
+		t.Errorf("Footprint went from %d to %d after touching %d bytes", first, second, size)
 	}
 }
 
@@ -165,6 +205,47 @@ func TestValue(t *testing.T) {
 	}
 }
 
+func TestStartTime(t *testing.T) {
+	p := &Process{ID: mypid}
+	start, err := p.StartTime()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d := time.Since(start); d < -time.Second || d > time.Hour {
+		t.Errorf("Started %v ago", d)
+	}
+}
+
+func TestCPUTime(t *testing.T) {
+	for end := time.Now().Add(50 * time.Millisecond); time.Now().Before(end); {
+	}
+	p := &Process{ID: mypid}
+	cpu, err := p.CPUTime()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cpu <= 0 {
+		t.Errorf("Got CPU time %v", cpu)
+	}
+}
+
+func TestState(t *testing.T) {
+	p := &Process{ID: mypid}
+	state, err := p.State()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if state != StateRunning && state != StateSleeping {
+		t.Errorf("Got state %v, want running or sleeping", state)
+	}
+	if s := StateDiskWait.String(); s != "disk-wait" {
+		t.Errorf("Got %q, want %q", s, "disk-wait")
+	}
+	if s := State(42).String(); s != "State(42)" {
+		t.Errorf("Got %q, want %q", s, "State(42)")
+	}
+}
+
 func TestGetDevNames(t *testing.T) {
 	devMutex.Lock()
 	devNames = nil
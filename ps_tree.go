@@ -14,6 +14,12 @@ func GetProcessMap() *ProcessMap {
 	if err != nil {
 		return nil
 	}
+	return newProcessMap(procs)
+}
+
+// newProcessMap returns a process map of procs, filling in the Children slice
+// of each process.
+func newProcessMap(procs []*Process) *ProcessMap {
 	pm := &ProcessMap{
 		Pids:     map[int]*Process{},
 		Children: map[int][]int{},